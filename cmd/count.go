@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+//exit codes used by Execute, so scripts can tell a search that found nothing apart from one that failed
+const (
+	ExitNoMatches = 1
+	ExitError     = 2
+)
+
+//ErrNoMatches is returned by --exists when nothing matched. It isn't printed, the exit code is enough
+var ErrNoMatches = errors.New("no matches")
+
+//countFlags holds the flags that check how many results there are instead of printing them
+type countFlags struct {
+	command *cobra.Command
+	count   bool
+	exists  bool
+}
+
+func (flags *countFlags) addFlags(command *cobra.Command) {
+	flags.command = command
+
+	commandFlags := command.Flags()
+	commandFlags.BoolVar(&flags.count, "count", false, "only print the number of results")
+	commandFlags.BoolVar(&flags.exists, "exists", false, "print nothing and exit with 0 if anything matched or 1 if nothing did")
+}
+
+//enabled is true when the results should be counted rather than printed
+func (flags *countFlags) enabled() bool {
+	return flags.count || flags.exists
+}
+
+func (flags *countFlags) name() string {
+	if flags.exists {
+		return "exists"
+	}
+
+	return "count"
+}
+
+func (flags *countFlags) validate() error {
+	if flags.count && flags.exists {
+		return errors.New("--count can't be combined with --exists")
+	}
+
+	if !flags.enabled() {
+		return nil
+	}
+
+	//nothing is printed, and every result is counted
+	for _, flagName := range []string{"output", "columns", "joiner", "format", "limit", "offset"} {
+		if flag := flags.command.Flags().Lookup(flagName); flag != nil && flag.Changed {
+			return fmt.Errorf("--%v can't be combined with --%v", flagName, flags.name())
+		}
+	}
+
+	return nil
+}
+
+//apply turns off relations and paging, since the results are only counted
+func (flags *countFlags) apply(repo *search.SearchRepository) {
+	repo.SetExpandOptions(search.ExpandOptions{Depth: 0, Mode: search.RelationsFull})
+	repo.SetPageOptions(search.PageOptions{})
+}
+
+//report prints the number of results for --count, or returns ErrNoMatches for --exists if there weren't any
+func (flags *countFlags) report(total int) error {
+	if flags.exists {
+		if total == 0 {
+			flags.command.SilenceErrors = true
+			flags.command.SilenceUsage = true
+			return ErrNoMatches
+		}
+
+		return nil
+	}
+
+	fmt.Println(total)
+	return nil
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"sort"
+	"strings"
+
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+//defaultJoiner separates the items of slice fields like tags in CSV and TSV output
+const defaultJoiner = ";"
+
+//columnValues follows a dotted column path like "organization.name" through the record.
+//Lists of related records give one value for each record, so "assigned_tickets.subject" is every subject
+func columnValues(value interface{}, path []string) []interface{} {
+	if len(path) == 0 {
+		return []interface{}{value}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		fieldValue, hasField := v[path[0]]
+		if !hasField {
+			return nil
+		}
+
+		return columnValues(fieldValue, path[1:])
+	case []map[string]interface{}:
+		var values []interface{}
+		for _, record := range v {
+			values = append(values, columnValues(record, path)...)
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
+
+//delimitedCell joins the values for one cell. Slice fields are flattened so every item is separated by the joiner
+func delimitedCell(values []interface{}, joiner string) string {
+	var items []string
+
+	for _, value := range values {
+		if slice, isSlice := value.([]interface{}); isSlice {
+			for _, item := range slice {
+				items = append(items, cellText(item))
+			}
+
+			continue
+		}
+
+		items = append(items, cellText(value))
+	}
+
+	return strings.Join(items, joiner)
+}
+
+//delimitedColumns lists the columns for the records: every field of the entity type in the order of its field list,
+//then the extra columns and any other fields, then the fields of the related records prefixed with the relation name.
+//Related records only get columns for fields they have, so summaries stay short
+func delimitedColumns(schema search.Schema, entity string, records []map[string]interface{}, extraColumns []string) []string {
+	columns := append([]string{}, entityFields(schema, entity)...)
+
+	for _, column := range extraColumns {
+		if !stringInSlice(column, columns) {
+			columns = append(columns, column)
+		}
+	}
+
+	return append(columns, relationColumns(schema, entity, records, "", columns)...)
+}
+
+func relationColumns(schema search.Schema, entity string, records []map[string]interface{}, prefix string, skip []string) []string {
+	var columns []string
+	relationNames := map[string]bool{}
+
+	for _, relation := range schema.Relations(entity) {
+		relationNames[relation.Name] = true
+
+		var related []map[string]interface{}
+		isRecord := false
+
+		for _, record := range records {
+			switch value := record[relation.Name].(type) {
+			case map[string]interface{}:
+				related = append(related, value)
+				isRecord = true
+			case []map[string]interface{}:
+				related = append(related, value...)
+				isRecord = true
+			case nil:
+			default:
+				//relations shown as IDs get a single column
+				if !stringInSlice(prefix+relation.Name, columns) {
+					columns = append(columns, prefix+relation.Name)
+				}
+			}
+		}
+
+		if !isRecord {
+			continue
+		}
+
+		for _, fieldName := range entityFields(schema, relation.To) {
+			for _, record := range related {
+				if _, hasField := record[fieldName]; hasField {
+					columns = append(columns, prefix+relation.Name+"."+fieldName)
+					break
+				}
+			}
+		}
+
+		columns = append(columns, relationColumns(schema, relation.To, related, prefix+relation.Name+".", nil)...)
+	}
+
+	//fields that aren't in the field list, like ones only some exports have, go after the known ones in alphabetical order
+	if prefix == "" {
+		var unknown []string
+		for _, record := range records {
+			for fieldName := range record {
+				if !stringInSlice(fieldName, skip) && !relationNames[fieldName] && !stringInSlice(fieldName, unknown) {
+					unknown = append(unknown, fieldName)
+				}
+			}
+		}
+
+		sort.Strings(unknown)
+		columns = append(unknown, columns...)
+	}
+
+	return columns
+}
+
+//formatDelimited writes the records with a header row. Quoting follows RFC 4180, and CSV lines end in CRLF as the RFC asks
+func formatDelimited(records []map[string]interface{}, columns []string, delimiter rune, joiner string) (string, error) {
+	var output strings.Builder
+
+	writer := csv.NewWriter(&output)
+	writer.Comma = delimiter
+	writer.UseCRLF = delimiter == ','
+
+	if err := writer.Write(columns); err != nil {
+		return "", err
+	}
+
+	for _, record := range records {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = delimitedCell(columnValues(record, strings.Split(column, ".")), joiner)
+		}
+
+		if err := writer.Write(row); err != nil {
+			return "", err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+
+	//the results are printed with Println, which adds the last line break
+	return strings.TrimRight(output.String(), "\r\n"), nil
+}
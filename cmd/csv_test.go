@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+func Test_formatDelimited(t *testing.T) {
+	tests := []struct {
+		name           string
+		records        []map[string]interface{}
+		columns        []string
+		delimiter      rune
+		expectedOutput string
+	}{
+		{
+			name:           "plain values",
+			records:        []map[string]interface{}{{"_id": float64(1), "name": "Enthaze", "shared": false}},
+			columns:        []string{"_id", "name", "shared"},
+			delimiter:      ',',
+			expectedOutput: "_id,name,shared\r\n1,Enthaze,false",
+		},
+		{
+			name:           "embedded quotes",
+			records:        []map[string]interface{}{{"signature": `Don't "Worry" Be Happy!`}},
+			columns:        []string{"signature"},
+			delimiter:      ',',
+			expectedOutput: "signature\r\n\"Don't \"\"Worry\"\" Be Happy!\"",
+		},
+		{
+			name:           "embedded commas",
+			records:        []map[string]interface{}{{"name": "Burt, Woodard", "alias": "Mr Burt"}},
+			columns:        []string{"name", "alias"},
+			delimiter:      ',',
+			expectedOutput: "name,alias\r\n\"Burt, Woodard\",Mr Burt",
+		},
+		{
+			//encoding/csv writes line breaks inside quoted cells as CRLF too
+			name:           "embedded newlines",
+			records:        []map[string]interface{}{{"_id": float64(1), "description": "line one\nline two"}},
+			columns:        []string{"_id", "description"},
+			delimiter:      ',',
+			expectedOutput: "_id,description\r\n1,\"line one\r\nline two\"",
+		},
+		{
+			name:           "tabs only quote what has tabs",
+			records:        []map[string]interface{}{{"name": "Burt, Woodard", "details": "a\tb"}},
+			columns:        []string{"name", "details"},
+			delimiter:      '\t',
+			expectedOutput: "name\tdetails\nBurt, Woodard\t\"a\tb\"",
+		},
+		{
+			name:           "missing fields are empty",
+			records:        []map[string]interface{}{{"_id": float64(1)}, {"_id": float64(2), "name": "Enthaze"}},
+			columns:        []string{"_id", "name"},
+			delimiter:      ',',
+			expectedOutput: "_id,name\r\n1,\r\n2,Enthaze",
+		},
+		{
+			name: "lists and related records",
+			records: []map[string]interface{}{{
+				"tags":             []interface{}{"Kieler", "Salvo"},
+				"organization":     map[string]interface{}{"name": "Multron"},
+				"assigned_tickets": []map[string]interface{}{{"subject": "A Problem"}, {"subject": "A Nuisance"}},
+			}},
+			columns:        []string{"tags", "organization.name", "assigned_tickets.subject"},
+			delimiter:      ',',
+			expectedOutput: "tags,organization.name,assigned_tickets.subject\r\nKieler;Salvo,Multron,A Problem;A Nuisance",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := formatDelimited(tt.records, tt.columns, tt.delimiter, defaultJoiner)
+
+			require.Nil(t, err)
+			require.Equal(t, tt.expectedOutput, output)
+		})
+	}
+}
+
+func Test_delimitedCell(t *testing.T) {
+	tests := []struct {
+		name         string
+		values       []interface{}
+		joiner       string
+		expectedCell string
+	}{
+		{name: "no values", values: nil, joiner: ";", expectedCell: ""},
+		{name: "one value", values: []interface{}{float64(101)}, joiner: ";", expectedCell: "101"},
+		{name: "list field", values: []interface{}{[]interface{}{"Kieler", "Salvo"}}, joiner: "|", expectedCell: "Kieler|Salvo"},
+		{name: "lists are flattened", values: []interface{}{[]interface{}{"a", "b"}, "c"}, joiner: ";", expectedCell: "a;b;c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expectedCell, delimitedCell(tt.values, tt.joiner))
+		})
+	}
+}
+
+func Test_delimitedColumns(t *testing.T) {
+	schema, err := search.ParseSchema([]byte(`{
+		"users": [
+			{"name": "_id", "type": "int"},
+			{"name": "name", "type": "string"},
+			{"name": "organization_id", "type": "reference", "target": "organizations", "inverse": "users"}
+		],
+		"organizations": [
+			{"name": "_id", "type": "int"},
+			{"name": "name", "type": "string"}
+		]
+	}`), nil)
+	require.Nil(t, err)
+
+	records := []map[string]interface{}{
+		{"_id": float64(1), "name": "user 1", "nickname": "one", "organization": map[string]interface{}{"name": "org 1"}},
+		{"_id": float64(2), "name": "user 2", "_score": float64(1)},
+	}
+
+	//fields that aren't in the schema go after the extra columns, and related records only get columns for the fields they have
+	require.Equal(t,
+		[]string{"_id", "name", "organization_id", "_score", "nickname", "organization.name"},
+		delimitedColumns(schema, search.UsersGroup, records, []string{"_score"}))
+
+	//relations shown as IDs get a single column
+	idRecords := []map[string]interface{}{{"_id": float64(22), "users": []interface{}{float64(1), float64(2)}}}
+	require.Equal(t, []string{"_id", "name", "users"}, delimitedColumns(schema, search.OrganizationsGroup, idRecords, nil))
+}
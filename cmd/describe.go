@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+//describe can only print tables and JSON, the other output formats are for records
+var describeFormats = []string{outputTable, outputJSON}
+
+//DescribeCommand shows what values the fields of one type of record have
+type DescribeCommand struct {
+	cobra      *cobra.Command
+	repository *search.SearchRepository
+	schema     search.Schema
+	entity     string
+	top        int
+	format     string
+}
+
+func NewDescribeCommand(repo *search.SearchRepository, schema search.Schema, entity string) *DescribeCommand {
+	describeCmd := &DescribeCommand{
+		repository: repo,
+		schema:     schema,
+		entity:     entity,
+	}
+
+	label := entityLabel(entity)
+
+	command := &cobra.Command{
+		Use:   "describe [field]",
+		Short: fmt.Sprintf("show the types and values of the %s fields.", label),
+		Long:  fmt.Sprintf(`show the types and values of the %s fields. Without a field every field is summed up in one table: the type worked out from the data, how many %s have the field, have it empty or don't have it at all, and how many distinct values it has. Giving a field also lists its most common values, and numbers and dates show their lowest and highest value. Items of list fields like tags are counted one at a time.`, label, label),
+		Args: func(command *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("too many arguments")
+			}
+
+			if len(args) == 1 {
+				if err := validateField(schema, entity, args[0]); err != nil {
+					return err
+				}
+			}
+
+			if describeCmd.top < 0 {
+				return errors.New("--top can't be negative")
+			}
+
+			if !stringInSlice(describeCmd.format, describeFormats) {
+				return fmt.Errorf(`Invalid output format "%v", expected one of: %v`, describeCmd.format, strings.Join(describeFormats, ", "))
+			}
+
+			return nil
+		},
+		RunE: describeCmd.RunCommand,
+	}
+
+	flags := command.Flags()
+	flags.IntVar(&describeCmd.top, "top", 10, "how many of the most common values are listed (0 lists them all)")
+	flags.StringVar(&describeCmd.format, "output", outputTable, "how the stats are printed: "+strings.Join(describeFormats, "|"))
+	describeCmd.cobra = command
+
+	return describeCmd
+}
+
+func (dc *DescribeCommand) RunCommand(command *cobra.Command, args []string) error {
+	var output interface{}
+	var text string
+
+	if len(args) == 1 {
+		stats := dc.repository.Describe(dc.entity, args[0], dc.top)
+		output, text = stats, formatFieldStats(stats)
+	} else {
+		var allStats []search.FieldStats
+		for _, fieldName := range entityFields(dc.schema, dc.entity) {
+			allStats = append(allStats, dc.repository.Describe(dc.entity, fieldName, dc.top))
+		}
+
+		output, text = allStats, formatFieldStatsTable(allStats)
+	}
+
+	if dc.format == outputJSON {
+		jsonOutput, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		text = string(jsonOutput)
+	}
+
+	fmt.Println(text)
+	return nil
+}
+
+//countText shows a count with its share of the records, like "70 (93.3%)"
+func countText(count int, records int) string {
+	if records == 0 {
+		return strconv.Itoa(count)
+	}
+
+	return fmt.Sprintf("%d (%.1f%%)", count, 100*float64(count)/float64(records))
+}
+
+//statsRow turns the stats into a record so they can be printed with formatTable
+func statsRow(stats search.FieldStats) map[string]interface{} {
+	return map[string]interface{}{
+		"field":    stats.Field,
+		"type":     string(stats.Type),
+		"present":  countText(stats.Present, stats.Records),
+		"empty":    countText(stats.Empty, stats.Records),
+		"missing":  countText(stats.Missing, stats.Records),
+		"distinct": float64(stats.Distinct),
+		"min":      stats.Min,
+		"max":      stats.Max,
+	}
+}
+
+func formatFieldStatsTable(allStats []search.FieldStats) string {
+	rows := make([]map[string]interface{}, len(allStats))
+	for i, stats := range allStats {
+		rows[i] = statsRow(stats)
+	}
+
+	return formatTable(rows, []string{"field", "type", "present", "empty", "missing", "distinct", "min", "max"}, terminalWidth())
+}
+
+//formatFieldStats prints the stats of one field as a list, followed by a table of its most common values
+func formatFieldStats(stats search.FieldStats) string {
+	row := statsRow(stats)
+
+	labels := []string{"type", "present", "empty", "missing", "distinct"}
+	if stats.Min != nil {
+		labels = append(labels, "min", "max")
+	}
+
+	lines := []string{fmt.Sprintf("%-10s%s", "field", stats.Field), fmt.Sprintf("%-10s%d", "records", stats.Records)}
+	for _, label := range labels {
+		lines = append(lines, fmt.Sprintf("%-10s%s", label, cellText(row[label])))
+	}
+
+	if len(stats.TopValues) > 0 {
+		values := make([]map[string]interface{}, len(stats.TopValues))
+		for i, valueCount := range stats.TopValues {
+			values[i] = map[string]interface{}{
+				"value": valueCount.Value,
+				"count": countText(valueCount.Count, stats.Records),
+			}
+		}
+
+		lines = append(lines, "", formatTable(values, []string{"value", "count"}, terminalWidth()))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+//entityLabel is how a type of record is written in help text, like "ticket comments"
+func entityLabel(entity string) string {
+	return strings.Replace(entity, "_", " ", -1)
+}
+
+//EntitySearchCommand searches one type of record by field
+type EntitySearchCommand struct {
+	cobra      *cobra.Command
+	repository *search.SearchRepository
+	entity     string
+	options    searchOptions
+	expand     expandFlags
+	output     outputFlags
+	page       pageFlags
+	count      countFlags
+	group      groupFlags
+
+	Formatter func([]map[string]interface{}) (string, error)
+}
+
+func NewEntitySearchCommand(repo *search.SearchRepository, schema search.Schema, entity string) *EntitySearchCommand {
+	entityCmd := &EntitySearchCommand{
+		Formatter:  formatJSONOutput,
+		repository: repo,
+		entity:     entity,
+	}
+
+	label := entityLabel(entity)
+
+	command := &cobra.Command{
+		Use:   "search [field] [search term]",
+		Short: fmt.Sprintf("search zendesk %s by field.", label),
+		Long:  fmt.Sprintf(`search zendesk %s by field. If the search term is omitted, it will return all %s that have the chosen field empty. Numeric fields can be searched by range, like 10..25, >=50 or <3. Date fields can be searched with --before, --after and --between. Use --match to search text by case-insensitive, partial or wildcard matches, or --regex to use a regular expression.`, label, label),
+		Args: func(command *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("requires a field argument")
+			}
+
+			if len(args) > 2 {
+				return errors.New("too many arguments")
+			}
+
+			if err := validateField(schema, entity, args[0]); err != nil {
+				return err
+			}
+
+			if err := entityCmd.expand.validate(entity); err != nil {
+				return err
+			}
+
+			if err := entityCmd.output.validate(); err != nil {
+				return err
+			}
+
+			if err := entityCmd.page.validate(); err != nil {
+				return err
+			}
+
+			if err := entityCmd.count.validate(); err != nil {
+				return err
+			}
+
+			if err := entityCmd.group.validate(); err != nil {
+				return err
+			}
+
+			return entityCmd.options.validate(args)
+		},
+		RunE: entityCmd.RunCommand,
+	}
+
+	entityCmd.options.addFlags(command, schema, entity)
+	entityCmd.expand.addFlags(command, schema)
+	entityCmd.output.addFlags(command, schema, entity)
+	entityCmd.output.fields = &entityCmd.expand.fields
+	entityCmd.page.addFlags(command, schema, entity)
+	entityCmd.count.addFlags(command)
+	entityCmd.group.addFlags(command, schema, entity)
+	entityCmd.cobra = command
+
+	return entityCmd
+}
+
+func (ec *EntitySearchCommand) RunCommand(command *cobra.Command, args []string) error {
+	var fieldName = args[0]
+
+	if err := ec.options.apply(ec.repository); err != nil {
+		return err
+	}
+
+	ec.expand.apply(ec.repository)
+	ec.page.apply(ec.repository)
+
+	if ec.count.enabled() {
+		ec.count.apply(ec.repository)
+		return ec.count.report(len(ec.repository.Find(ec.entity, fieldName, ec.options.term)))
+	}
+
+	if ec.group.enabled() {
+		ec.group.apply(ec.repository)
+		return ec.group.report(ec.repository.Find(ec.entity, fieldName, ec.options.term))
+	}
+
+	if ec.output.streaming() {
+		return ec.repository.Stream(ec.entity, fieldName, ec.options.term, ec.output.recordWriter(""))
+	}
+
+	searchResults := ec.repository.Find(ec.entity, fieldName, ec.options.term)
+
+	formatter := ec.output.formatter(ec.entity, ec.Formatter)
+
+	formattedResults, err := formatter(searchResults)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(formattedResults)
+	return nil
+}
+
+//NewEntityTextCommand searches the indexed fields of the schema, so it is only added for types of record that have some
+func NewEntityTextCommand(repo *search.SearchRepository, schema search.Schema, entity string) *TextSearchCommand {
+	textFields := strings.Join(schema.Entity(entity).IndexedFields(), " and ")
+
+	return newTextSearchCommand(repo, schema, entity, textFields,
+		func(query string, explain bool) []map[string]interface{} {
+			return repo.FindByText(entity, query, explain)
+		},
+		func(query string, explain bool, emit search.RecordHandler) error {
+			return repo.StreamByText(entity, query, explain, emit)
+		},
+	)
+}
+
+//newQueryEntity is used by both the query command of the type of record and the cross entity query
+func newQueryEntity(repo *search.SearchRepository, entity string) queryEntity {
+	return queryEntity{
+		name: entity,
+		queryFunc: func(query search.QueryNode) []map[string]interface{} {
+			return repo.Query(entity, query)
+		},
+		streamFunc: func(query search.QueryNode, emit search.RecordHandler) error {
+			return repo.StreamQuery(entity, query, emit)
+		},
+	}
+}
+
+func NewEntityQueryCommand(repo *search.SearchRepository, schema search.Schema, entity string) *QueryCommand {
+	queryEntity := newQueryEntity(repo, entity)
+	return newQueryCommand(repo, schema, entity, queryEntity.queryFunc, queryEntity.streamFunc)
+}
+
+//NewEntityCommand adds the commands for one type of record: fields, search, query, describe and text if it has indexed fields.
+//The command is named after the type, with dashes instead of underscores, like "ticket-comments"
+func NewEntityCommand(repo *search.SearchRepository, schema search.Schema, entity string) *cobra.Command {
+	label := entityLabel(entity)
+
+	rootCmd := &cobra.Command{
+		Use:   strings.Replace(entity, "_", "-", -1),
+		Short: fmt.Sprintf("zendesk %s operations", label),
+		Long:  fmt.Sprintf(`zendesk %s operations`, label),
+	}
+
+	if rootCmd.Use != entity {
+		rootCmd.Aliases = []string{entity}
+	}
+
+	fieldsCmd := &cobra.Command{
+		Use:   "fields",
+		Short: fmt.Sprintf("list valid %s fields to search by", label),
+		Long:  fmt.Sprintf(`list valid %s fields to search by`, label),
+		Run: func(command *cobra.Command, args []string) {
+			for _, fieldName := range entityFields(schema, entity) {
+				fmt.Println(fieldName)
+			}
+		},
+	}
+
+	searchCmd := NewEntitySearchCommand(repo, schema, entity)
+
+	queryCmd := NewEntityQueryCommand(repo, schema, entity)
+
+	describeCmd := NewDescribeCommand(repo, schema, entity)
+
+	rootCmd.AddCommand(fieldsCmd, searchCmd.cobra, queryCmd.cobra, describeCmd.cobra)
+
+	if len(schema.Entity(entity).IndexedFields()) > 0 {
+		textCmd := NewEntityTextCommand(repo, schema, entity)
+		rootCmd.AddCommand(textCmd.cobra)
+	}
+
+	return rootCmd
+}
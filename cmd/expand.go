@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+//expandFlags holds the flags that choose which related records are added to results
+type expandFlags struct {
+	command   *cobra.Command
+	schema    search.Schema
+	relations []string
+	noExpand  bool
+	depth     int
+	mode      string
+	summaries []string
+	fields    []string
+
+	options search.ExpandOptions //built by validate
+}
+
+func (flags *expandFlags) addFlags(command *cobra.Command, schema search.Schema) {
+	flags.command = command
+	flags.schema = schema
+
+	commandFlags := command.Flags()
+	commandFlags.StringSliceVar(&flags.relations, "expand", nil, "only add these related records (comma separated, like organization,assigned_tickets)")
+	commandFlags.BoolVar(&flags.noExpand, "no-expand", false, "don't add any related records")
+	commandFlags.IntVar(&flags.depth, "depth", search.DefaultExpandOptions.Depth, "how many levels of related records to add, like 2 for the organization of a ticket's assigned user")
+	commandFlags.StringVar(&flags.mode, "relations", search.RelationsFull, "how related records are shown: "+strings.Join(search.RelationModes, "|"))
+	commandFlags.StringSliceVar(&flags.fields, "fields", nil, "only include these fields in the results (comma separated). Fields of related records can be picked like organization.name")
+	commandFlags.StringArrayVar(&flags.summaries, "summary-fields", nil, "the fields shown for a type of record with --relations summary, like tickets=_id,subject,priority (can be repeated)")
+}
+
+//validate builds the expand options. The relations and fields only need to be valid for one of the entity types, since cross entity commands search several
+func (flags *expandFlags) validate(entities ...string) error {
+	if err := flags.validateFields(entities); err != nil {
+		return err
+	}
+
+	if flags.noExpand {
+		for _, flagName := range []string{"expand", "depth", "relations", "summary-fields"} {
+			if flags.command.Flags().Changed(flagName) {
+				return fmt.Errorf("--no-expand can't be combined with --%v", flagName)
+			}
+		}
+
+		flags.options = search.ExpandOptions{Depth: 0, Fields: flags.fields}
+		return nil
+	}
+
+	summaryFields, err := parseSummaryFields(flags.schema, flags.summaries)
+	if err != nil {
+		return err
+	}
+
+	flags.options = search.ExpandOptions{
+		Relations:     flags.relations,
+		Depth:         flags.depth,
+		Mode:          flags.mode,
+		SummaryFields: summaryFields,
+		Fields:        flags.fields,
+	}
+
+	//only follow the relations the fields need, unless the depth was asked for
+	if len(flags.fields) > 0 && !flags.command.Flags().Changed("depth") {
+		flags.options.Depth = 0
+		for _, entity := range entities {
+			if depth := search.FieldsDepth(flags.schema, entity, flags.fields); depth > flags.options.Depth {
+				flags.options.Depth = depth
+			}
+		}
+	}
+
+	for _, entity := range entities {
+		if err = search.ValidateExpandOptions(flags.schema, entity, flags.options); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+//validateFields checks --fields against the field lists of the entity types, following relations for fields like organization.name
+func (flags *expandFlags) validateFields(entities []string) error {
+	for _, field := range flags.fields {
+		valid := false
+		for _, entity := range entities {
+			if validColumnPath(flags.schema, entity, strings.Split(field, ".")) {
+				valid = true
+				break
+			}
+		}
+
+		if !valid {
+			return fmt.Errorf(`Invalid field "%v"`, field)
+		}
+	}
+
+	return nil
+}
+
+func (flags *expandFlags) apply(repo *search.SearchRepository) {
+	repo.SetExpandOptions(flags.options)
+}
+
+//parseSummaryFields reads --summary-fields values like "tickets=_id,subject,priority"
+func parseSummaryFields(schema search.Schema, values []string) (map[string][]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	summaryFields := make(map[string][]string, len(values))
+
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf(`Invalid --summary-fields "%v", expected a type and its fields like tickets=_id,subject`, value)
+		}
+
+		entity := parts[0]
+		fields := entityFields(schema, entity)
+		if fields == nil {
+			return nil, fmt.Errorf(`Invalid --summary-fields "%v", unknown type "%v"`, value, entity)
+		}
+
+		for _, fieldName := range strings.Split(parts[1], ",") {
+			if !stringInSlice(fieldName, fields) {
+				return nil, fmt.Errorf(`Invalid --summary-fields "%v", %v have no field "%v"`, value, entity, fieldName)
+			}
+
+			summaryFields[entity] = append(summaryFields[entity], fieldName)
+		}
+	}
+
+	return summaryFields, nil
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+//GlobalSearchCommand searches every field of every type of record
+type GlobalSearchCommand struct {
+	cobra      *cobra.Command
+	repository *search.SearchRepository
+	matchMode  string
+	fieldNames []string
+	output     outputFlags
+	page       pageFlags
+	count      countFlags
+
+	Formatter func(map[string][]map[string]interface{}) (string, error)
+}
+
+func NewGlobalSearchCommand(repo *search.SearchRepository, schema search.Schema) *GlobalSearchCommand {
+	globalCmd := &GlobalSearchCommand{
+		Formatter:  formatGroupedJSONOutput,
+		repository: repo,
+	}
+
+	command := &cobra.Command{
+		Use:   "search [search term]",
+		Short: "search every field of every type of record.",
+		Long:  `search every field of every type of record. Results are grouped by type and list the fields that matched in "_matched_fields". By default any field containing the term matches, ignoring case.`,
+		Args: func(command *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("requires a search term")
+			}
+
+			if len(args) > 1 {
+				return errors.New("too many arguments")
+			}
+
+			for _, fieldName := range globalCmd.fieldNames {
+				valid := false
+				for _, entity := range search.EntityGroups {
+					valid = valid || stringInSlice(fieldName, entityFields(schema, entity))
+				}
+
+				if !valid {
+					return fmt.Errorf(`Invalid field "%v"`, fieldName)
+				}
+			}
+
+			if _, err := search.MatcherForMode(globalCmd.matchMode); err != nil {
+				return err
+			}
+
+			if err := globalCmd.output.validate(); err != nil {
+				return err
+			}
+
+			if err := globalCmd.page.validate(); err != nil {
+				return err
+			}
+
+			return globalCmd.count.validate()
+		},
+		RunE: globalCmd.RunCommand,
+	}
+
+	flags := command.Flags()
+	flags.StringVar(&globalCmd.matchMode, "match", search.MatchContains, "how text is compared to the search term: "+strings.Join(search.MatchModes, "|"))
+	flags.StringSliceVar(&globalCmd.fieldNames, "fields", nil, "only search these fields (comma separated)")
+
+	globalCmd.output.addFlags(command, schema, search.EntityGroups...)
+	globalCmd.output.extraColumns = []string{"_matched_fields"}
+	globalCmd.page.addFlags(command, schema, search.EntityGroups...)
+	globalCmd.count.addFlags(command)
+	globalCmd.cobra = command
+
+	return globalCmd
+}
+
+func (gc *GlobalSearchCommand) RunCommand(command *cobra.Command, args []string) error {
+	if err := gc.repository.SetMatchMode(gc.matchMode); err != nil {
+		return err
+	}
+
+	gc.page.apply(gc.repository)
+
+	if gc.count.enabled() {
+		gc.count.apply(gc.repository)
+	}
+
+	results := gc.repository.FindEverywhere(args[0], gc.fieldNames)
+
+	if gc.count.enabled() {
+		total := 0
+		for _, records := range results {
+			total += len(records)
+		}
+
+		return gc.count.report(total)
+	}
+
+	//the results don't have their relations added so they are already small, but they are still written a line at a time
+	if gc.output.streaming() {
+		for _, group := range search.EntityGroups {
+			writeRecord := gc.output.recordWriter(group)
+			for _, record := range results[group] {
+				if err := writeRecord(record); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	formatter := gc.output.groupedFormatter(gc.Formatter)
+
+	formattedResults, err := formatter(results)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(formattedResults)
+	return nil
+}
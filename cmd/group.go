@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+//groupCountColumn holds the size of each bucket in the --group-by output
+const groupCountColumn = "count"
+
+//groupFlags holds --group-by, which counts the results by the values of some fields instead of printing them
+type groupFlags struct {
+	command  *cobra.Command
+	schema   search.Schema
+	entities []string
+	fields   []string
+}
+
+//addFlags adds --group-by to the command. entities are the types of record the command can return
+func (flags *groupFlags) addFlags(command *cobra.Command, schema search.Schema, entities ...string) {
+	flags.command = command
+	flags.schema = schema
+	flags.entities = entities
+
+	command.Flags().StringSliceVar(&flags.fields, "group-by", nil, "count the results for each value of these fields instead of printing them (comma separated). Fields of related records can be picked like organization.name, and list fields like tags count each item")
+}
+
+func (flags *groupFlags) enabled() bool {
+	return len(flags.fields) > 0
+}
+
+//validFieldPath is like validColumnPath, but the path has to end in a field rather than a related record
+func validFieldPath(schema search.Schema, entity string, path []string) bool {
+	if len(path) == 1 {
+		return stringInSlice(path[0], entityFields(schema, entity))
+	}
+
+	for _, relation := range schema.Relations(entity) {
+		if relation.Name == path[0] {
+			return validFieldPath(schema, relation.To, path[1:])
+		}
+	}
+
+	return false
+}
+
+func (flags *groupFlags) validate() error {
+	if !flags.enabled() {
+		return nil
+	}
+
+	for _, field := range flags.fields {
+		valid := false
+		for _, entity := range flags.entities {
+			valid = valid || validFieldPath(flags.schema, entity, strings.Split(field, "."))
+		}
+
+		if !valid {
+			return fmt.Errorf(`Invalid group-by field "%v"`, field)
+		}
+	}
+
+	//every result is counted, and the relations are only added to reach the grouped fields
+	for _, flagName := range []string{"count", "exists", "columns", "joiner", "format", "sort", "limit", "offset", "expand", "no-expand", "depth", "relations", "summary-fields", "fields"} {
+		if flag := flags.command.Flags().Lookup(flagName); flag != nil && flag.Changed {
+			return fmt.Errorf("--%v can't be combined with --group-by", flagName)
+		}
+	}
+
+	if format := flags.format(); format != outputJSON && format != outputTable {
+		return fmt.Errorf("--group-by only works with --output %v or %v", outputJSON, outputTable)
+	}
+
+	return nil
+}
+
+func (flags *groupFlags) format() string {
+	if flag := flags.command.Flags().Lookup("output"); flag != nil {
+		return flag.Value.String()
+	}
+
+	return outputJSON
+}
+
+//apply only adds the related records the grouped fields need, and turns off paging so every result is counted
+func (flags *groupFlags) apply(repo *search.SearchRepository) {
+	depth := 0
+	for _, entity := range flags.entities {
+		if entityDepth := search.FieldsDepth(flags.schema, entity, flags.fields); entityDepth > depth {
+			depth = entityDepth
+		}
+	}
+
+	repo.SetExpandOptions(search.ExpandOptions{Fields: flags.fields, Depth: depth, Mode: search.RelationsFull})
+	repo.SetPageOptions(search.PageOptions{})
+}
+
+//rows turns the buckets into records shaped like the results, so organization.name becomes {"organization": {"name": ...}}.
+//That way they print the same way with --output json and table
+func (flags *groupFlags) rows(records []map[string]interface{}) []map[string]interface{} {
+	buckets := search.GroupRecords(records, flags.fields)
+
+	rows := make([]map[string]interface{}, len(buckets))
+	for i, bucket := range buckets {
+		row := map[string]interface{}{groupCountColumn: float64(bucket.Count)}
+
+		for j, field := range flags.fields {
+			path := strings.Split(field, ".")
+
+			parent := row
+			for _, name := range path[:len(path)-1] {
+				child, isMap := parent[name].(map[string]interface{})
+				if !isMap {
+					child = map[string]interface{}{}
+					parent[name] = child
+				}
+
+				parent = child
+			}
+
+			parent[path[len(path)-1]] = bucket.Values[j]
+		}
+
+		rows[i] = row
+	}
+
+	return rows
+}
+
+func (flags *groupFlags) formatRows(rows []map[string]interface{}) (string, error) {
+	if flags.format() == outputTable {
+		return formatTable(rows, append(append([]string{}, flags.fields...), groupCountColumn), terminalWidth()), nil
+	}
+
+	return formatJSONOutput(rows)
+}
+
+//report prints the counts for the results of one type of record
+func (flags *groupFlags) report(records []map[string]interface{}) error {
+	formatted, err := flags.formatRows(flags.rows(records))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(formatted)
+	return nil
+}
+
+//reportGroups prints the counts for commands that return more than one type of record, with the buckets of each type kept apart
+func (flags *groupFlags) reportGroups(groups map[string][]map[string]interface{}) error {
+	rowGroups := make(map[string][]map[string]interface{}, len(groups))
+	for groupName, records := range groups {
+		rowGroups[groupName] = flags.rows(records)
+	}
+
+	if flags.format() != outputTable {
+		formatted, err := formatGroupedJSONOutput(rowGroups)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(formatted)
+		return nil
+	}
+
+	var sections []string
+	for _, groupName := range sortedGroupNames(rowGroups) {
+		formatted, err := flags.formatRows(rowGroups[groupName])
+		if err != nil {
+			return err
+		}
+
+		sections = append(sections, fmt.Sprintf("%s (%d)\n%s", groupName, len(groups[groupName]), formatted))
+	}
+
+	fmt.Println(strings.Join(sections, "\n\n"))
+	return nil
+}
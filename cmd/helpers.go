@@ -20,3 +20,14 @@ func formatJSONOutput(data []map[string]interface{}) (string, error) {
 
 	return string(output), nil
 }
+
+//formatGroupedJSONOutput is used for results that cover more than one type of record
+func formatGroupedJSONOutput(data map[string][]map[string]interface{}) (string, error) {
+	output, err := json.MarshalIndent(data, "", "  ")
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(output), nil
+}
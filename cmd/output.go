@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+//output formats that can be chosen with --output
+const (
+	outputJSON   = "json"
+	outputTable  = "table"
+	outputCSV    = "csv"
+	outputTSV    = "tsv"
+	outputNDJSON = "ndjson"
+)
+
+var outputFormats = []string{outputJSON, outputTable, outputCSV, outputTSV, outputNDJSON}
+
+//defaultColumns are the table columns shown for each type of record when --columns isn't used. Types left out show every field
+var defaultColumns = map[string][]string{
+	search.UsersGroup:               {"_id", "name", "email", "role", "organization_id", "active"},
+	search.OrganizationsGroup:       {"_id", "name", "domain_names", "details"},
+	search.TicketsGroup:             {"_id", "subject", "type", "priority", "status", "assignee_id"},
+	search.GroupsGroup:              {"_id", "name", "description", "default"},
+	search.TicketCommentsGroup:      {"_id", "ticket_id", "author_id", "body", "public"},
+	search.SatisfactionRatingsGroup: {"_id", "ticket_id", "score", "comment"},
+}
+
+//defaultTerminalWidth is used for tables when the width of the terminal can't be found
+const defaultTerminalWidth = 120
+
+//terminalWidth uses the COLUMNS environment variable if it is set, otherwise it asks the terminal
+func terminalWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+
+	if width := terminalSize(); width > 0 {
+		return width
+	}
+
+	return defaultTerminalWidth
+}
+
+//outputFlags holds the flags that choose how results are printed
+type outputFlags struct {
+	command  *cobra.Command
+	schema   search.Schema
+	entities []string
+	format   string
+	columns  []string
+	joiner   string
+	tmplText string
+
+	template *template.Template //parsed from --format by validate
+	//extraColumns are added by the command to the records, like "_score", and are shown after the default columns
+	extraColumns []string
+	//fields points at the command's --fields, which pick the columns when --columns isn't used
+	fields *[]string
+}
+
+//addFlags adds the output flags to the command. entities are the types of record the command can return
+func (flags *outputFlags) addFlags(command *cobra.Command, schema search.Schema, entities ...string) {
+	flags.command = command
+	flags.schema = schema
+	flags.entities = entities
+
+	commandFlags := command.Flags()
+	commandFlags.StringVar(&flags.format, "output", outputJSON, "how results are printed: "+strings.Join(outputFormats, "|")+". ndjson writes one result per line, but --sort, --limit and --offset hold every result in memory to sort them before the first line is written")
+	commandFlags.StringSliceVar(&flags.columns, "columns", nil, "the columns shown with --output table, csv or tsv (comma separated). Fields of related records can be picked like organization.name")
+	commandFlags.StringVar(&flags.joiner, "joiner", defaultJoiner, "what separates the items of list fields like tags with --output csv or tsv")
+	commandFlags.StringVar(&flags.tmplText, "format", "", `print each result with a Go template, like '{{.name}} <{{.email}}>'. The helpers join, date and truncate can be used, like {{.tags | join ", "}}, {{date "2006-01-02" .created_at}} and {{truncate 30 .subject}}`)
+}
+
+//validColumn checks if the column is a field or relation of at least one of the command's types of record.
+//Columns like organization.name are checked by following the relations
+func (flags *outputFlags) validColumn(column string) bool {
+	if stringInSlice(column, flags.extraColumns) {
+		return true
+	}
+
+	for _, entity := range flags.entities {
+		if validColumnPath(flags.schema, entity, strings.Split(column, ".")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validColumnPath(schema search.Schema, entity string, path []string) bool {
+	if len(path) == 1 && stringInSlice(path[0], entityFields(schema, entity)) {
+		return true
+	}
+
+	for _, relation := range schema.Relations(entity) {
+		if relation.Name == path[0] {
+			return len(path) == 1 || validColumnPath(schema, relation.To, path[1:])
+		}
+	}
+
+	return false
+}
+
+func (flags *outputFlags) delimited() bool {
+	return flags.format == outputCSV || flags.format == outputTSV
+}
+
+func (flags *outputFlags) validate() error {
+	if !stringInSlice(flags.format, outputFormats) {
+		return fmt.Errorf(`Invalid output format "%v", expected one of: %v`, flags.format, strings.Join(outputFormats, ", "))
+	}
+
+	if len(flags.columns) > 0 && flags.format != outputTable && !flags.delimited() {
+		return fmt.Errorf("--columns only works with --output %v, %v or %v", outputTable, outputCSV, outputTSV)
+	}
+
+	if flags.command.Flags().Changed("joiner") && !flags.delimited() {
+		return fmt.Errorf("--joiner only works with --output %v or %v", outputCSV, outputTSV)
+	}
+
+	//a CSV file can only have one header row, so the types of record can't be mixed
+	if flags.delimited() && len(flags.entities) > 1 {
+		return fmt.Errorf("--output %v only works on commands that return one type of record", flags.format)
+	}
+
+	for _, column := range flags.columns {
+		if !flags.validColumn(column) {
+			return fmt.Errorf(`Invalid column "%v"`, column)
+		}
+	}
+
+	flags.template = nil
+
+	if flags.tmplText != "" {
+		if flags.command.Flags().Changed("output") {
+			return errors.New("--format can't be combined with --output")
+		}
+
+		tmpl, err := parseFormatTemplate(flags.tmplText)
+		if err != nil {
+			return err
+		}
+
+		flags.template = tmpl
+	}
+
+	return nil
+}
+
+//fieldColumns are the columns for the fields picked with --fields, followed by the extra columns
+func (flags *outputFlags) fieldColumns() []string {
+	if flags.fields == nil || len(*flags.fields) == 0 {
+		return nil
+	}
+
+	columns := append([]string{}, *flags.fields...)
+	for _, column := range flags.extraColumns {
+		if !stringInSlice(column, columns) {
+			columns = append(columns, column)
+		}
+	}
+
+	return columns
+}
+
+func (flags *outputFlags) columnsFor(entity string) []string {
+	if len(flags.columns) > 0 {
+		return flags.columns
+	}
+
+	if columns := flags.fieldColumns(); len(columns) > 0 {
+		return columns
+	}
+
+	columns, hasDefault := defaultColumns[entity]
+	if !hasDefault {
+		//types of record without default columns show every field in the schema
+		columns = entityFields(flags.schema, entity)
+	}
+
+	return append(append([]string{}, columns...), flags.extraColumns...)
+}
+
+//formatter picks the formatter for --output. defaultFormatter is the command's own Formatter, which is used for JSON
+func (flags *outputFlags) formatter(entity string, defaultFormatter func([]map[string]interface{}) (string, error)) func([]map[string]interface{}) (string, error) {
+	if flags.template != nil {
+		return func(records []map[string]interface{}) (string, error) {
+			return formatTemplate(flags.template, flags.schema, entity, records)
+		}
+	}
+
+	switch flags.format {
+	case outputTable:
+		return func(records []map[string]interface{}) (string, error) {
+			return formatTable(records, flags.columnsFor(entity), terminalWidth()), nil
+		}
+	case outputCSV, outputTSV:
+		delimiter := ','
+		if flags.format == outputTSV {
+			delimiter = '\t'
+		}
+
+		return func(records []map[string]interface{}) (string, error) {
+			columns := flags.columns
+			if len(columns) == 0 {
+				columns = flags.fieldColumns()
+			}
+
+			if len(columns) == 0 {
+				columns = delimitedColumns(flags.schema, entity, records, flags.extraColumns)
+			}
+
+			return formatDelimited(records, columns, delimiter, flags.joiner)
+		}
+	default:
+		return defaultFormatter
+	}
+}
+
+//streaming is true when results are written one at a time as they are found, rather than formatted all together
+func (flags *outputFlags) streaming() bool {
+	return flags.format == outputNDJSON
+}
+
+//recordWriter writes each record to stdout as one line of JSON. For commands that return more than one type of record,
+//group is added to the record as "_type"
+func (flags *outputFlags) recordWriter(group string) search.RecordHandler {
+	encoder := json.NewEncoder(os.Stdout)
+
+	return func(record map[string]interface{}) error {
+		if group != "" {
+			record["_type"] = group
+		}
+
+		return encoder.Encode(record)
+	}
+}
+
+//groupedFormatter is formatter for commands that return more than one type of record
+func (flags *outputFlags) groupedFormatter(defaultFormatter func(map[string][]map[string]interface{}) (string, error)) func(map[string][]map[string]interface{}) (string, error) {
+	if flags.format == outputJSON && flags.template == nil {
+		return defaultFormatter
+	}
+
+	if flags.template != nil {
+		return func(groups map[string][]map[string]interface{}) (string, error) {
+			var sections []string
+
+			for _, groupName := range sortedGroupNames(groups) {
+				//templates can use {{._type}} to tell the types of record apart
+				for _, record := range groups[groupName] {
+					record["_type"] = groupName
+				}
+
+				formatted, err := formatTemplate(flags.template, flags.schema, groupName, groups[groupName])
+				if err != nil {
+					return "", err
+				}
+
+				if formatted != "" {
+					sections = append(sections, formatted)
+				}
+			}
+
+			return strings.Join(sections, "\n"), nil
+		}
+	}
+
+	return func(groups map[string][]map[string]interface{}) (string, error) {
+		groupNames := sortedGroupNames(groups)
+
+		sections := make([]string, len(groupNames))
+		for i, groupName := range groupNames {
+			formatted, err := flags.formatter(groupName, nil)(groups[groupName])
+			if err != nil {
+				return "", err
+			}
+
+			sections[i] = fmt.Sprintf("%s (%d)\n%s", groupName, len(groups[groupName]), formatted)
+		}
+
+		return strings.Join(sections, "\n\n"), nil
+	}
+}
+
+func sortedGroupNames(groups map[string][]map[string]interface{}) []string {
+	groupNames := make([]string, 0, len(groups))
+	for groupName := range groups {
+		groupNames = append(groupNames, groupName)
+	}
+
+	sort.Strings(groupNames)
+	return groupNames
+}
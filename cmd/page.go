@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+//pageFlags holds the flags that choose the order of the results and how many are shown
+type pageFlags struct {
+	schema   search.Schema
+	entities []string
+	sort     []string
+	limit    int
+	offset   int
+
+	//extraFields can be sorted by as well as the fields of the entity types, like "_score"
+	extraFields []string
+
+	options search.PageOptions //built by validate
+}
+
+//addFlags adds the page flags to the command. entities are the types of record the command can return
+func (flags *pageFlags) addFlags(command *cobra.Command, schema search.Schema, entities ...string) {
+	flags.schema = schema
+	flags.entities = entities
+
+	commandFlags := command.Flags()
+	commandFlags.StringSliceVar(&flags.sort, "sort", nil, "sort the results by a field, like created_at:desc. Repeat it to break ties (default _id)")
+	commandFlags.IntVar(&flags.limit, "limit", 0, "only show this many results (0 shows them all)")
+	commandFlags.IntVar(&flags.offset, "offset", 0, "skip this many results, use it with --limit to page through them")
+}
+
+func (flags *pageFlags) validate() error {
+	if flags.limit < 0 {
+		return errors.New("--limit can't be negative")
+	}
+
+	if flags.offset < 0 {
+		return errors.New("--offset can't be negative")
+	}
+
+	flags.options = search.PageOptions{Limit: flags.limit, Offset: flags.offset}
+
+	for _, sortText := range flags.sort {
+		key, err := search.ParseSortKey(sortText)
+		if err != nil {
+			return err
+		}
+
+		valid := stringInSlice(key.Field, flags.extraFields)
+		for _, entity := range flags.entities {
+			valid = valid || stringInSlice(key.Field, entityFields(flags.schema, entity))
+		}
+
+		if !valid {
+			return fmt.Errorf(`Invalid sort field "%v"`, key.Field)
+		}
+
+		flags.options.Sort = append(flags.options.Sort, key)
+	}
+
+	return nil
+}
+
+func (flags *pageFlags) apply(repo *search.SearchRepository) {
+	repo.SetPageOptions(flags.options)
+}
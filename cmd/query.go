@@ -0,0 +1,318 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+const queryHelp = `Queries are made of field:value terms combined with AND, OR, NOT and brackets, for example:
+
+  status:pending AND priority:high AND NOT tags:Ohio
+  (type:incident OR type:problem) AND has_incidents:true
+
+Values with spaces or brackets need "double quotes". Numeric fields can use ranges like _id:10..25.
+AND binds tighter than OR, and terms next to each other are ANDed together.`
+
+//parseQueryArgs joins the arguments so the query doesn't have to be quoted as a whole
+func parseQueryArgs(args []string) (string, search.QueryNode, error) {
+	if len(args) < 1 {
+		return "", nil, errors.New("requires a query argument")
+	}
+
+	query := strings.Join(args, " ")
+
+	node, err := search.ParseQuery(query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return query, node, nil
+}
+
+//QueryCommand searches one type of record with the query language
+type QueryCommand struct {
+	cobra      *cobra.Command
+	repository *search.SearchRepository
+	queryFunc  func(query search.QueryNode) []map[string]interface{}
+	streamFunc func(query search.QueryNode, emit search.RecordHandler) error
+	matchMode  string
+	node       search.QueryNode
+	expand     expandFlags
+	output     outputFlags
+	page       pageFlags
+	count      countFlags
+	group      groupFlags
+
+	Formatter func([]map[string]interface{}) (string, error)
+}
+
+func newQueryCommand(repo *search.SearchRepository, schema search.Schema, entityName string, queryFunc func(query search.QueryNode) []map[string]interface{}, streamFunc func(query search.QueryNode, emit search.RecordHandler) error) *QueryCommand {
+	queryCmd := &QueryCommand{
+		Formatter:  formatJSONOutput,
+		repository: repo,
+		queryFunc:  queryFunc,
+		streamFunc: streamFunc,
+	}
+
+	command := &cobra.Command{
+		Use:   "query [query]",
+		Short: fmt.Sprintf("search zendesk %s with a query combining several fields.", entityName),
+		Long:  fmt.Sprintf("search zendesk %s with a query combining several fields.\n\n%s", entityName, queryHelp),
+		Args: func(command *cobra.Command, args []string) error {
+			query, node, err := parseQueryArgs(args)
+			if err != nil {
+				return err
+			}
+
+			typedNode, err := search.TypeQuery(query, node, schema.Entity(entityName))
+			if err != nil {
+				return err
+			}
+
+			if _, err := search.MatcherForMode(queryCmd.matchMode); err != nil {
+				return err
+			}
+
+			if err := queryCmd.expand.validate(entityName); err != nil {
+				return err
+			}
+
+			if err := queryCmd.output.validate(); err != nil {
+				return err
+			}
+
+			if err := queryCmd.page.validate(); err != nil {
+				return err
+			}
+
+			if err := queryCmd.count.validate(); err != nil {
+				return err
+			}
+
+			if err := queryCmd.group.validate(); err != nil {
+				return err
+			}
+
+			queryCmd.node = typedNode
+			return nil
+		},
+		RunE: queryCmd.RunCommand,
+	}
+
+	command.Flags().StringVar(&queryCmd.matchMode, "match", search.MatchExact, "how text is compared to the values in the query: "+strings.Join(search.MatchModes, "|"))
+	queryCmd.expand.addFlags(command, schema)
+	queryCmd.output.addFlags(command, schema, entityName)
+	queryCmd.output.fields = &queryCmd.expand.fields
+	queryCmd.page.addFlags(command, schema, entityName)
+	queryCmd.count.addFlags(command)
+	queryCmd.group.addFlags(command, schema, entityName)
+	queryCmd.cobra = command
+
+	return queryCmd
+}
+
+func (qc *QueryCommand) RunCommand(command *cobra.Command, args []string) error {
+	if err := qc.repository.SetMatchMode(qc.matchMode); err != nil {
+		return err
+	}
+
+	qc.expand.apply(qc.repository)
+	qc.page.apply(qc.repository)
+
+	if qc.count.enabled() {
+		qc.count.apply(qc.repository)
+		return qc.count.report(len(qc.queryFunc(qc.node)))
+	}
+
+	if qc.group.enabled() {
+		qc.group.apply(qc.repository)
+		return qc.group.report(qc.queryFunc(qc.node))
+	}
+
+	if qc.output.streaming() {
+		return qc.streamFunc(qc.node, qc.output.recordWriter(""))
+	}
+
+	formatter := qc.output.formatter(qc.output.entities[0], qc.Formatter)
+
+	formattedResults, err := formatter(qc.queryFunc(qc.node))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(formattedResults)
+	return nil
+}
+
+//queryEntity is one type of record the cross entity query can search
+type queryEntity struct {
+	name       string
+	node       search.QueryNode //the query with its values parsed for this type of record
+	queryFunc  func(query search.QueryNode) []map[string]interface{}
+	streamFunc func(query search.QueryNode, emit search.RecordHandler) error
+}
+
+//GlobalQueryCommand runs a query against every type of record that has all the fields used in the query
+type GlobalQueryCommand struct {
+	cobra      *cobra.Command
+	repository *search.SearchRepository
+	entities   []queryEntity
+	matchMode  string
+	matched    []queryEntity
+	expand     expandFlags
+	output     outputFlags
+	page       pageFlags
+	count      countFlags
+	group      groupFlags
+
+	Formatter func(map[string][]map[string]interface{}) (string, error)
+}
+
+func NewGlobalQueryCommand(repo *search.SearchRepository, schema search.Schema) *GlobalQueryCommand {
+	queryCmd := &GlobalQueryCommand{
+		Formatter:  formatGroupedJSONOutput,
+		repository: repo,
+	}
+
+	for _, entity := range search.EntityGroups {
+		queryCmd.entities = append(queryCmd.entities, newQueryEntity(repo, entity))
+	}
+
+	command := &cobra.Command{
+		Use:   "query [query]",
+		Short: "search every type of record with one query.",
+		Long: "search every type of record with one query. The query is run against every type of record that has all the fields it uses, and the results are grouped by type.\n\n" +
+			queryHelp,
+		Args: func(command *cobra.Command, args []string) error {
+			query, node, err := parseQueryArgs(args)
+			if err != nil {
+				return err
+			}
+
+			if _, err := search.MatcherForMode(queryCmd.matchMode); err != nil {
+				return err
+			}
+
+			queryCmd.matched = nil
+
+			for _, entity := range queryCmd.entities {
+				hasFields := true
+				for _, term := range node.Terms() {
+					if !stringInSlice(term.Field, entityFields(schema, entity.name)) {
+						hasFields = false
+						break
+					}
+				}
+
+				if !hasFields {
+					continue
+				}
+
+				if entity.node, err = search.TypeQuery(query, node, schema.Entity(entity.name)); err != nil {
+					return err
+				}
+
+				queryCmd.matched = append(queryCmd.matched, entity)
+			}
+
+			if len(queryCmd.matched) == 0 {
+				return errors.New("no type of record has all the fields used in the query")
+			}
+
+			matchedNames := make([]string, len(queryCmd.matched))
+			for i, entity := range queryCmd.matched {
+				matchedNames[i] = entity.name
+			}
+
+			if err := queryCmd.expand.validate(matchedNames...); err != nil {
+				return err
+			}
+
+			if err := queryCmd.output.validate(); err != nil {
+				return err
+			}
+
+			if err := queryCmd.page.validate(); err != nil {
+				return err
+			}
+
+			if err := queryCmd.count.validate(); err != nil {
+				return err
+			}
+
+			return queryCmd.group.validate()
+		},
+		RunE: queryCmd.RunCommand,
+	}
+
+	command.Flags().StringVar(&queryCmd.matchMode, "match", search.MatchExact, "how text is compared to the values in the query: "+strings.Join(search.MatchModes, "|"))
+	queryCmd.expand.addFlags(command, schema)
+	queryCmd.output.addFlags(command, schema, search.EntityGroups...)
+	queryCmd.output.fields = &queryCmd.expand.fields
+	queryCmd.page.addFlags(command, schema, search.EntityGroups...)
+	queryCmd.count.addFlags(command)
+	queryCmd.group.addFlags(command, schema, search.EntityGroups...)
+	queryCmd.cobra = command
+
+	return queryCmd
+}
+
+func (gc *GlobalQueryCommand) RunCommand(command *cobra.Command, args []string) error {
+	if err := gc.repository.SetMatchMode(gc.matchMode); err != nil {
+		return err
+	}
+
+	gc.expand.apply(gc.repository)
+	gc.page.apply(gc.repository)
+
+	if gc.count.enabled() {
+		gc.count.apply(gc.repository)
+
+		total := 0
+		for _, entity := range gc.matched {
+			total += len(entity.queryFunc(entity.node))
+		}
+
+		return gc.count.report(total)
+	}
+
+	if gc.group.enabled() {
+		gc.group.apply(gc.repository)
+
+		results := make(map[string][]map[string]interface{}, len(gc.matched))
+		for _, entity := range gc.matched {
+			results[entity.name] = entity.queryFunc(entity.node)
+		}
+
+		return gc.group.reportGroups(results)
+	}
+
+	if gc.output.streaming() {
+		for _, entity := range gc.matched {
+			if err := entity.streamFunc(entity.node, gc.output.recordWriter(entity.name)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	results := make(map[string][]map[string]interface{}, len(gc.matched))
+	for _, entity := range gc.matched {
+		results[entity.name] = entity.queryFunc(entity.node)
+	}
+
+	formatter := gc.output.groupedFormatter(gc.Formatter)
+
+	formattedResults, err := formatter(results)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(formattedResults)
+	return nil
+}
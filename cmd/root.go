@@ -3,11 +3,29 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/superjinjo/zendesk-search/search"
 )
 
+//DataDirEnvVar can be set instead of passing --data-dir on every call
+const DataDirEnvVar = "ZENSEARCH_DATA_DIR"
+
+//DataSources holds the data file locations chosen on the command line.
+//Empty values mean the files embedded in the binary should be used
+type DataSources struct {
+	DataDir string
+	//Files holds the files given for single types of record with flags like --users-file, by type of record
+	Files map[string]string
+
+	//Schema describes the fields of the data, it is search.DefaultSchema unless --schema-file is used
+	Schema search.Schema
+}
+
+//RepositoryLoader builds the search repository out of the chosen data files
+type RepositoryLoader func(sources DataSources) (*search.SearchRepository, error)
+
 type BaseCommand struct {
 	cobra.Command
 	repository *search.SearchRepository
@@ -25,23 +43,94 @@ func newBaseCommand(command cobra.Command, repo *search.SearchRepository) BaseCo
 func NewRootCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "zensearch",
-		Short: "zensearch allows you to search users, organizations, and tickets",
-		Long:  `zensearch allows you to search users, organizations, and tickets by any attribute and will provide any related data`,
+		Short: "zensearch allows you to search users, organizations, tickets, groups, ticket comments and satisfaction ratings",
+		Long:  `zensearch allows you to search users, organizations, tickets, groups, ticket comments and satisfaction ratings by any attribute and will provide any related data`,
 	}
 }
 
-func Execute(repository *search.SearchRepository) {
+//addSearchCommands adds every command that searches the data. The shell uses it too
+func addSearchCommands(rootCmd *cobra.Command, repo *search.SearchRepository, schema search.Schema) {
+	for _, entity := range search.EntityGroups {
+		rootCmd.AddCommand(NewEntityCommand(repo, schema, entity))
+	}
+
+	queryCmd := NewGlobalQueryCommand(repo, schema)
+	searchCmd := NewGlobalSearchCommand(repo, schema)
 
+	rootCmd.AddCommand(queryCmd.cobra, searchCmd.cobra)
+}
+
+//NewZensearchCmd builds the whole command tree for the schema, see SchemaFromArgs. The data files are only loaded once the flags have been parsed
+func NewZensearchCmd(loader RepositoryLoader, schema search.Schema) *cobra.Command {
 	rootCmd := NewRootCmd()
 
-	usersCmd := NewUsersCommand(repository)
-	orgsCmd := NewOrganizationsCommand(repository)
-	ticketsCmd := NewTicketsCommand(repository)
+	sources := DataSources{Schema: schema}
+	var schemaFile string
+
+	flags := rootCmd.PersistentFlags()
+	flags.StringVar(&sources.DataDir, "data-dir", os.Getenv(DataDirEnvVar), "directory containing users.json, organizations.json and tickets.json, and optionally groups.json, ticket_comments.json and satisfaction_ratings.json (env "+DataDirEnvVar+")")
+
+	entityFiles := make(map[string]*string, len(search.EntityGroups))
+	for _, entity := range search.EntityGroups {
+		entityFiles[entity] = flags.String(dataFileFlag(entity), "", entityLabel(entity)+" JSON file, overrides --data-dir")
+	}
+
+	//the file is already read by SchemaFromArgs, the flag is only here so it shows up in the help and parses
+	flags.StringVar(&schemaFile, schemaFileFlag, "", `JSON file describing the fields of the data, see "zensearch schema"`)
+
+	//the commands are built before the flags are parsed, so they share a repository that gets filled in before they run
+	repository := new(search.SearchRepository)
+
+	rootCmd.PersistentPreRunE = func(command *cobra.Command, args []string) error {
+		sources.Files = make(map[string]string, len(entityFiles))
+		for entity, file := range entityFiles {
+			sources.Files[entity] = *file
+		}
 
-	rootCmd.AddCommand(usersCmd, orgsCmd, ticketsCmd)
+		loadedRepo, err := loader(sources)
+		if err != nil {
+			//the usage text doesn't help when the problem is in a data file
+			command.SilenceUsage = true
+			return err
+		}
+
+		*repository = *loadedRepo
+		return nil
+	}
+
+	addSearchCommands(rootCmd, repository, schema)
+
+	shellCmd := NewShellCommand(repository, schema)
+	rootCmd.AddCommand(shellCmd.cobra, NewSchemaCommand(schema))
+
+	return rootCmd
+}
+
+//dataFileFlag is the flag that gives the file for one type of record, like --ticket-comments-file.
+//Organizations keep the shorter --orgs-file
+func dataFileFlag(entity string) string {
+	if entity == search.OrganizationsGroup {
+		return "orgs-file"
+	}
+
+	return strings.Replace(entity, "_", "-", -1) + "-file"
+}
+
+func Execute(loader RepositoryLoader) {
+	schema, err := SchemaFromArgs(os.Args[1:])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(ExitError)
+	}
+
+	rootCmd := NewZensearchCmd(loader, schema)
 
 	if err := rootCmd.Execute(); err != nil {
+		if err == ErrNoMatches {
+			os.Exit(ExitNoMatches)
+		}
+
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(ExitError)
 	}
 }
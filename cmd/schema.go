@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+//schemaFileFlag is the flag that points to a schema file
+const schemaFileFlag = "schema-file"
+
+//entityFields are the fields of each type of record, keyed by the names used in search.Relation
+func entityFields(schema search.Schema, entity string) []string {
+	if _, hasEntity := schema[entity]; !hasEntity {
+		return nil
+	}
+
+	return schema.Entity(entity).FieldNames()
+}
+
+//validateField checks that the field belongs to the type of record
+func validateField(schema search.Schema, entity string, fieldName string) error {
+	if !stringInSlice(fieldName, entityFields(schema, entity)) {
+		return fmt.Errorf(`Invalid field "%v"`, fieldName)
+	}
+
+	return nil
+}
+
+//ReadSchemaFile reads a schema file, which only has to describe what it changes from search.DefaultSchema
+func ReadSchemaFile(path string) (search.Schema, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := search.ParseSchema(data, search.DefaultSchema)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %v: %v", path, err)
+	}
+
+	return schema, nil
+}
+
+//SchemaFromArgs finds --schema-file in the command line arguments and reads it. The schema decides which commands and fields
+//there are, so it is needed before the command tree can be built. Any other flags are left for cobra to check
+func SchemaFromArgs(args []string) (search.Schema, error) {
+	flags := pflag.NewFlagSet("zensearch", pflag.ContinueOnError)
+	flags.ParseErrorsWhitelist.UnknownFlags = true
+	flags.Usage = func() {}
+	flags.SetOutput(ioutil.Discard)
+
+	path := flags.String(schemaFileFlag, "", "")
+	//pflag stops at --help when it isn't defined, and the help should show the commands of the schema too
+	flags.BoolP("help", "h", false, "")
+
+	//mistakes in the other arguments, like -h or a flag missing its value, are reported once cobra parses them
+	if err := flags.Parse(args); err != nil || *path == "" {
+		return search.DefaultSchema, nil
+	}
+
+	return ReadSchemaFile(*path)
+}
+
+//NewSchemaCommand prints the schema in use, which is a good starting point for a --schema-file
+func NewSchemaCommand(schema search.Schema) *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "print the fields and field types of every type of record",
+		Long:  `print the fields and field types of every type of record as JSON. Save it to a file, edit it and pass it to --schema-file to search data with other fields.`,
+		RunE: func(command *cobra.Command, args []string) error {
+			output, err := json.MarshalIndent(schema, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(output))
+			return nil
+		},
+	}
+}
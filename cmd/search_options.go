@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+//searchOptions holds the flags shared by the search commands of every type of record
+type searchOptions struct {
+	command   *cobra.Command
+	schema    search.EntitySchema
+	matchMode string
+	regex     bool
+	before    string
+	after     string
+	between   string
+
+	pattern *regexp.Regexp //compiled from the search term by validate when --regex is set
+	term    interface{}    //the search term parsed by validate, ready to pass to the repository. With --regex it is the pattern itself
+}
+
+//addFlags adds the shared flags to the command. entity is the type of record searched, its schema decides how terms are read
+func (opts *searchOptions) addFlags(command *cobra.Command, schema search.Schema, entity string) {
+	opts.command = command
+	opts.schema = schema.Entity(entity)
+
+	flags := command.Flags()
+	flags.StringVar(&opts.matchMode, "match", search.MatchExact, "how text is compared to the search term: "+strings.Join(search.MatchModes, "|"))
+	flags.BoolVar(&opts.regex, "regex", false, "treat the search term as a regular expression")
+	flags.StringVar(&opts.before, "before", "", "only match dates before this date (like 2016-06-01 or -30d)")
+	flags.StringVar(&opts.after, "after", "", "only match dates after this date (like 2016-06-01 or -30d)")
+	flags.StringVar(&opts.between, "between", "", "only match dates between two dates, including both (like 2016-01-01,2016-06-30). A date without a time includes the whole day")
+}
+
+func (opts *searchOptions) hasDateFlags() bool {
+	return opts.before != "" || opts.after != "" || opts.between != ""
+}
+
+//dateRange builds the range asked for by --before, --after or --between
+func (opts *searchOptions) dateRange(now time.Time) (search.DateRange, error) {
+	var dateRange search.DateRange
+	var err error
+
+	if opts.between != "" {
+		if opts.before != "" || opts.after != "" {
+			return dateRange, errors.New("--between can't be combined with --before or --after")
+		}
+
+		bounds := strings.Split(opts.between, ",")
+		if len(bounds) != 2 {
+			return dateRange, fmt.Errorf(`Invalid --between "%v", expected two dates separated by a comma`, opts.between)
+		}
+
+		if dateRange.From, err = search.ParseDateExpression(bounds[0], now); err != nil {
+			return dateRange, err
+		}
+
+		//a date without a time includes the whole of that day
+		if dateRange.To, dateRange.ToInclusive, err = search.ParseDateRangeEnd(bounds[1], now); err != nil {
+			return dateRange, err
+		}
+
+		if !dateRange.From.Before(dateRange.To) && !(dateRange.ToInclusive && dateRange.From.Equal(dateRange.To)) {
+			return dateRange, fmt.Errorf(`Invalid --between "%v", the first date is after the second`, opts.between)
+		}
+
+		dateRange.FromInclusive = true
+
+		return dateRange, nil
+	}
+
+	if opts.after != "" {
+		if dateRange.From, err = search.ParseDateExpression(opts.after, now); err != nil {
+			return dateRange, err
+		}
+	}
+
+	if opts.before != "" {
+		if dateRange.To, err = search.ParseDateExpression(opts.before, now); err != nil {
+			return dateRange, err
+		}
+	}
+
+	return dateRange, nil
+}
+
+//validate gets called by the Args validators so that bad flag values are reported the same way as bad arguments
+func (opts *searchOptions) validate(args []string) error {
+	if _, err := search.MatcherForMode(opts.matchMode); err != nil {
+		return err
+	}
+
+	opts.pattern = nil
+	opts.term = ""
+
+	schema := opts.schema
+
+	if opts.hasDateFlags() {
+		dateFields := schema.FieldsOfType(search.FieldDateTime)
+		if !stringInSlice(args[0], dateFields) {
+			return fmt.Errorf("--before, --after and --between only work on the date fields: %v", strings.Join(dateFields, ", "))
+		}
+
+		if len(args) > 1 || opts.regex {
+			return errors.New("--before, --after and --between can't be combined with a search term")
+		}
+
+		dateRange, err := opts.dateRange(time.Now())
+		if err != nil {
+			return err
+		}
+
+		opts.term = dateRange
+		return nil
+	}
+
+	if len(args) > 1 && !opts.regex {
+		field, _ := schema.Field(args[0])
+
+		term, err := field.ParseTerm(args[1])
+		if err != nil {
+			return err
+		}
+
+		opts.term = term
+	}
+
+	if opts.regex {
+		if opts.command.Flags().Changed("match") {
+			return errors.New("--regex can't be combined with --match")
+		}
+
+		if len(args) < 2 {
+			return errors.New("--regex requires a search term")
+		}
+
+		//patterns are matched against text, so numbers and true/false fields would never match
+		if stringInSlice(args[0], schema.FieldsOfType(search.FieldInt, search.FieldBool)) {
+			return fmt.Errorf(`--regex only works on text fields, and "%v" isn't one`, args[0])
+		}
+
+		pattern, err := regexp.Compile(args[1])
+		if err != nil {
+			return fmt.Errorf(`Invalid regular expression "%v": %v`, args[1], err)
+		}
+
+		opts.pattern = pattern
+		opts.term = args[1]
+	}
+
+	return nil
+}
+
+//apply configures the repository for the search that is about to run
+func (opts *searchOptions) apply(repo *search.SearchRepository) error {
+	if opts.pattern != nil {
+		repo.SetValueMatcher(search.RegexpMatcher(opts.pattern))
+		repo.SetExactMatch(false)
+		return nil
+	}
+
+	return repo.SetMatchMode(opts.matchMode)
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+const shellHistoryFileName = ".zensearch_history"
+
+const shellHelp = `Enter commands the same way you would on the command line. The "zensearch" in front is optional:
+
+  users search role admin
+  tickets fields
+  organizations search name "Enthaze"
+  query status:pending AND priority:high
+
+Use "[command] --help" for more information about a command.
+
+Other commands:
+  help    show this message
+  quit    leave the shell (exit and Ctrl-D work too)`
+
+//newShellTree builds the commands available in the shell. It gets rebuilt for every line so that flag values don't leak between searches
+func newShellTree(repo *search.SearchRepository, schema search.Schema) *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:           "zensearch",
+		SilenceErrors: true,
+		//a mistake in the shell prints only the error, the usage text would push it off the screen
+		SilenceUsage: true,
+	}
+
+	addSearchCommands(rootCmd, repo, schema)
+
+	return rootCmd
+}
+
+//splitCommandLine splits a line into arguments the way a shell would, so quoted search terms can have spaces in them
+func splitCommandLine(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+	escaped := false
+
+	for _, char := range line {
+		switch {
+		case escaped:
+			current.WriteRune(char)
+			escaped = false
+		case char == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			} else {
+				current.WriteRune(char)
+			}
+		case char == '"' || char == '\'':
+			quote = char
+			inArg = true
+		case char == ' ' || char == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(char)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+
+	if escaped {
+		return nil, errors.New("line ends with an escape character")
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
+
+func defaultHistoryPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(homeDir, shellHistoryFileName)
+}
+
+type ShellCommand struct {
+	cobra       *cobra.Command
+	repository  *search.SearchRepository
+	schema      search.Schema
+	historyPath string
+}
+
+func NewShellCommand(repo *search.SearchRepository, schema search.Schema) *ShellCommand {
+	shellCmd := &ShellCommand{
+		repository: repo,
+		schema:     schema,
+	}
+
+	command := &cobra.Command{
+		Use:   "shell",
+		Short: "start an interactive search prompt",
+		Long:  `start an interactive search prompt. The data is loaded once and every search command can be run from the prompt.`,
+		Args:  cobra.NoArgs,
+		RunE:  shellCmd.RunCommand,
+	}
+
+	command.Flags().StringVar(&shellCmd.historyPath, "history-file", defaultHistoryPath(), "file the command history is saved to")
+
+	shellCmd.cobra = command
+
+	return shellCmd
+}
+
+func (sc *ShellCommand) loadHistory(line *liner.State) {
+	if sc.historyPath == "" {
+		return
+	}
+
+	//a missing history file just means this is the first time the shell has been used
+	if file, err := os.Open(sc.historyPath); err == nil {
+		defer file.Close()
+		line.ReadHistory(file)
+	}
+}
+
+func (sc *ShellCommand) saveHistory(line *liner.State) error {
+	if sc.historyPath == "" {
+		return nil
+	}
+
+	file, err := os.Create(sc.historyPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = line.WriteHistory(file)
+	return err
+}
+
+//runLine runs a single line of input and reports whether the shell should keep going
+func (sc *ShellCommand) runLine(input string) bool {
+	args, err := splitCommandLine(input)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return true
+	}
+
+	//commands pasted from the command line still work
+	if len(args) > 0 && args[0] == "zensearch" {
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		return true
+	}
+
+	switch args[0] {
+	case "quit", "exit":
+		return false
+	case "help":
+		if len(args) == 1 {
+			fmt.Println(shellHelp)
+			return true
+		}
+	}
+
+	shellTree := newShellTree(sc.repository, sc.schema)
+	shellTree.SetArgs(args)
+
+	if err := shellTree.Execute(); err == ErrNoMatches {
+		fmt.Println("No matches")
+	} else if err != nil {
+		fmt.Println("Error:", err)
+	}
+
+	return true
+}
+
+func (sc *ShellCommand) RunCommand(command *cobra.Command, args []string) error {
+	line := liner.NewLiner()
+	defer line.Close()
+
+	line.SetCtrlCAborts(true)
+	sc.loadHistory(line)
+
+	fmt.Println(`zensearch shell. Type "help" for a list of commands.`)
+
+	for {
+		input, err := line.Prompt("zensearch> ")
+		if err == liner.ErrPromptAborted {
+			//Ctrl-C only throws away the current line
+			continue
+		}
+
+		if err == io.EOF {
+			fmt.Println()
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimSpace(input) == "" {
+			continue
+		}
+
+		line.AppendHistory(input)
+
+		if !sc.runLine(input) {
+			break
+		}
+	}
+
+	return sc.saveHistory(line)
+}
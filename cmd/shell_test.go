@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_splitCommandLine(t *testing.T) {
+	tests := []struct {
+		name         string
+		line         string
+		expectedArgs []string
+		expectError  bool
+	}{
+		{name: "words", line: "users search role admin", expectedArgs: []string{"users", "search", "role", "admin"}},
+		{name: "extra spaces and tabs", line: "  users \t search  ", expectedArgs: []string{"users", "search"}},
+		{name: "empty line", line: "   ", expectedArgs: nil},
+		{name: "double quotes", line: `organizations search name "Mega Corp"`, expectedArgs: []string{"organizations", "search", "name", "Mega Corp"}},
+		{name: "single quotes", line: `users search signature 'Don"t Worry'`, expectedArgs: []string{"users", "search", "signature", `Don"t Worry`}},
+		{name: "empty quotes", line: `users search alias ""`, expectedArgs: []string{"users", "search", "alias", ""}},
+		{name: "quotes inside a word", line: `--format={{.name}}" "{{.email}}`, expectedArgs: []string{"--format={{.name}} {{.email}}"}},
+		{name: "escaped space", line: `tickets search subject A\ Catastrophe`, expectedArgs: []string{"tickets", "search", "subject", "A Catastrophe"}},
+		{name: "escaped quote in double quotes", line: `users search name "say \"hi\""`, expectedArgs: []string{"users", "search", "name", `say "hi"`}},
+		{name: "backslash in single quotes", line: `users search name 'a\b'`, expectedArgs: []string{"users", "search", "name", `a\b`}},
+		{name: "escaped backslash", line: `a\\b`, expectedArgs: []string{`a\b`}},
+		{name: "unterminated double quote", line: `users search name "Mega`, expectError: true},
+		{name: "unterminated single quote", line: `users search name 'Mega`, expectError: true},
+		{name: "trailing escape", line: `users search name Mega\`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := splitCommandLine(tt.line)
+
+			if tt.expectError {
+				require.NotNil(t, err)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	tableColumnGap      = "  "
+	tableMinColumnWidth = 4
+	tableEllipsis       = "…"
+)
+
+//cellText turns a field value into the text shown in a table cell. Related records are shown by their name, subject or ID
+func cellText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = cellText(item)
+		}
+
+		return strings.Join(items, ", ")
+	case map[string]interface{}:
+		for _, fieldName := range []string{"name", "subject", "_id"} {
+			if fieldValue, hasField := v[fieldName]; hasField {
+				return cellText(fieldValue)
+			}
+		}
+
+		return ""
+	case []string:
+		return strings.Join(v, ", ")
+	case []map[string]interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = cellText(item)
+		}
+
+		return strings.Join(items, ", ")
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+//truncateText cuts the text down to width characters, ending it with an ellipsis if anything was cut off
+func truncateText(text string, width int) string {
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+
+	if width <= 1 {
+		return tableEllipsis
+	}
+
+	return string([]rune(text)[:width-1]) + tableEllipsis
+}
+
+//fitColumnWidths shrinks the widest columns one character at a time until the table fits in maxWidth
+func fitColumnWidths(widths []int, maxWidth int) []int {
+	total := len(tableColumnGap) * (len(widths) - 1)
+	for _, width := range widths {
+		total += width
+	}
+
+	for total > maxWidth {
+		widest := 0
+		for i, width := range widths {
+			if width > widths[widest] {
+				widest = i
+			}
+		}
+
+		if widths[widest] <= tableMinColumnWidth {
+			break
+		}
+
+		widths[widest]--
+		total--
+	}
+
+	return widths
+}
+
+//formatTable lines the records up in columns that fit in maxWidth. Columns can be dotted paths like organization.name, and newlines in values are shown as spaces
+func formatTable(records []map[string]interface{}, columns []string, maxWidth int) string {
+	rows := make([][]string, len(records)+1)
+	widths := make([]int, len(columns))
+
+	rows[0] = columns
+	for i, record := range records {
+		row := make([]string, len(columns))
+		for j, column := range columns {
+			values := columnValues(record, strings.Split(column, "."))
+			row[j] = strings.Join(strings.Fields(cellText(values)), " ")
+		}
+
+		rows[i+1] = row
+	}
+
+	for _, row := range rows {
+		for j, cell := range row {
+			if width := utf8.RuneCountInString(cell); width > widths[j] {
+				widths[j] = width
+			}
+		}
+	}
+
+	widths = fitColumnWidths(widths, maxWidth)
+
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cell = truncateText(cell, widths[j])
+
+			cells[j] = cell + strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell))
+		}
+
+		//the padding is cut off the end so lines don't end in spaces, even when the last cells are empty
+		lines[i] = strings.TrimRight(strings.Join(cells, tableColumnGap), " ")
+	}
+
+	return strings.Join(lines, "\n")
+}
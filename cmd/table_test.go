@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_truncateText(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		width        int
+		expectedText string
+	}{
+		{name: "fits", text: "Enthaze", width: 10, expectedText: "Enthaze"},
+		{name: "exact fit", text: "Enthaze", width: 7, expectedText: "Enthaze"},
+		{name: "cut off", text: "Enthaze", width: 5, expectedText: "Enth…"},
+		{name: "counts characters not bytes", text: "Côpeland", width: 8, expectedText: "Côpeland"},
+		{name: "cuts multi byte characters whole", text: "Maé Bowman", width: 3, expectedText: "Ma…"},
+		{name: "width of one", text: "Enthaze", width: 1, expectedText: "…"},
+		{name: "width of zero", text: "Enthaze", width: 0, expectedText: "…"},
+		{name: "empty text", text: "", width: 0, expectedText: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expectedText, truncateText(tt.text, tt.width))
+		})
+	}
+}
+
+func Test_fitColumnWidths(t *testing.T) {
+	tests := []struct {
+		name           string
+		widths         []int
+		maxWidth       int
+		expectedWidths []int
+	}{
+		{name: "fits", widths: []int{3, 10, 5}, maxWidth: 40, expectedWidths: []int{3, 10, 5}},
+		{name: "gaps count", widths: []int{3, 10, 5}, maxWidth: 21, expectedWidths: []int{3, 9, 5}},
+		{name: "widest column shrinks first", widths: []int{20, 10, 5}, maxWidth: 30, expectedWidths: []int{11, 10, 5}},
+		{name: "wide columns shrink evenly", widths: []int{20, 20}, maxWidth: 22, expectedWidths: []int{10, 10}},
+		{name: "stops at the minimum width", widths: []int{10, 10}, maxWidth: 5, expectedWidths: []int{tableMinColumnWidth, tableMinColumnWidth}},
+		{name: "narrow columns are left alone", widths: []int{2, 3}, maxWidth: 1, expectedWidths: []int{2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expectedWidths, fitColumnWidths(tt.widths, tt.maxWidth))
+		})
+	}
+}
+
+func Test_cellText(t *testing.T) {
+	tests := []struct {
+		name         string
+		value        interface{}
+		expectedText string
+	}{
+		{name: "missing", value: nil, expectedText: ""},
+		{name: "whole number", value: float64(101), expectedText: "101"},
+		{name: "decimal", value: 2.5, expectedText: "2.5"},
+		{name: "bool", value: true, expectedText: "true"},
+		{name: "list", value: []interface{}{"Kieler", "Salvo"}, expectedText: "Kieler, Salvo"},
+		{name: "record with a name", value: map[string]interface{}{"_id": float64(101), "name": "Enthaze"}, expectedText: "Enthaze"},
+		{name: "record with a subject", value: map[string]interface{}{"_id": "abcd", "subject": "A Problem"}, expectedText: "A Problem"},
+		{name: "record with only an ID", value: map[string]interface{}{"_id": float64(5)}, expectedText: "5"},
+		{name: "list of records", value: []map[string]interface{}{{"name": "Enthaze"}, {"name": "Nutralab"}}, expectedText: "Enthaze, Nutralab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expectedText, cellText(tt.value))
+		})
+	}
+}
+
+func Test_formatTable(t *testing.T) {
+	records := []map[string]interface{}{
+		{"_id": float64(1), "name": "Francisca Rasmussen", "organization": map[string]interface{}{"name": "Multron"}},
+		{"_id": float64(2), "name": "Cross\nBarlow"},
+	}
+
+	require.Equal(t, strings.Join([]string{
+		"_id  name                 organization.name",
+		"1    Francisca Rasmussen  Multron",
+		"2    Cross Barlow",
+	}, "\n"), formatTable(records, []string{"_id", "name", "organization.name"}, 80))
+
+	//the name column is cut down to fit
+	require.Equal(t, strings.Join([]string{
+		"_id  name",
+		"1    Francisca…",
+		"2    Cross Bar…",
+	}, "\n"), formatTable(records, []string{"_id", "name"}, 15))
+}
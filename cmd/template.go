@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+//templateFuncs are the helpers --format templates can use. The value comes last so they work in pipelines, like {{.tags | join ", "}}
+var templateFuncs = template.FuncMap{
+	//join puts the items of a list field like tags together
+	"join": func(separator string, value interface{}) string {
+		switch v := value.(type) {
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = cellText(item)
+			}
+
+			return strings.Join(items, separator)
+		case []map[string]interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = cellText(item)
+			}
+
+			return strings.Join(items, separator)
+		default:
+			return cellText(value)
+		}
+	},
+	//date formats a timestamp from the data with a Go time layout, like {{date "2006-01-02" .created_at}}
+	"date": func(layout string, value interface{}) (string, error) {
+		text := cellText(value)
+		if text == "" {
+			return "", nil
+		}
+
+		parsed, err := search.ParseDataTime(text)
+		if err != nil {
+			return "", err
+		}
+
+		return parsed.Format(layout), nil
+	},
+	//truncate cuts text down to a number of characters, ending it with an ellipsis if anything was cut off
+	"truncate": func(width int, value interface{}) string {
+		return truncateText(cellText(value), width)
+	},
+}
+
+//parseFormatTemplate parses a --format template like '{{.name}} <{{.email}}>'
+func parseFormatTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid --format template: %v", err)
+	}
+
+	return tmpl, nil
+}
+
+//templateData copies the record for a template. Fields the record doesn't have are added as empty text, and relations to
+//a single record that weren't found are added as records of empty fields, so {{.organization.name}} prints nothing
+//for users that don't have an organization instead of "<no value>"
+func templateData(schema search.Schema, entity string, record map[string]interface{}) map[string]interface{} {
+	data := make(map[string]interface{}, len(record))
+	for key, value := range record {
+		data[key] = value
+	}
+
+	for _, fieldName := range entityFields(schema, entity) {
+		if _, hasField := data[fieldName]; !hasField {
+			data[fieldName] = ""
+		}
+	}
+
+	for _, relation := range schema.Relations(entity) {
+		if relation.Many {
+			continue
+		}
+
+		switch related := data[relation.Name].(type) {
+		case map[string]interface{}:
+			data[relation.Name] = templateData(schema, relation.To, related)
+		case nil:
+			data[relation.Name] = emptyTemplateRecord(schema, relation.To, map[string]bool{entity: true})
+		}
+	}
+
+	return data
+}
+
+//emptyTemplateRecord stands in for a related record that wasn't found. Its own single relations are filled in too,
+//except for types of record already on the way there, since a schema can have relations that lead back to the same type
+func emptyTemplateRecord(schema search.Schema, entity string, seen map[string]bool) map[string]interface{} {
+	record := map[string]interface{}{}
+	for _, fieldName := range entityFields(schema, entity) {
+		record[fieldName] = ""
+	}
+
+	seen[entity] = true
+	defer delete(seen, entity)
+
+	for _, relation := range schema.Relations(entity) {
+		if !relation.Many && !seen[relation.To] {
+			record[relation.Name] = emptyTemplateRecord(schema, relation.To, seen)
+		}
+	}
+
+	return record
+}
+
+//formatTemplate runs the template for each record, one record per line
+func formatTemplate(tmpl *template.Template, schema search.Schema, entity string, records []map[string]interface{}) (string, error) {
+	lines := make([]string, len(records))
+
+	for i, record := range records {
+		var line strings.Builder
+		if err := tmpl.Execute(&line, templateData(schema, entity, record)); err != nil {
+			return "", err
+		}
+
+		lines[i] = line.String()
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+func Test_templateFuncs(t *testing.T) {
+	tests := []struct {
+		name           string
+		format         string
+		record         map[string]interface{}
+		expectedOutput string
+		expectError    bool
+	}{
+		{name: "join", format: `{{.tags | join ", "}}`, record: map[string]interface{}{"tags": []interface{}{"Kieler", "Salvo"}}, expectedOutput: "Kieler, Salvo"},
+		{name: "join records", format: `{{.users | join "/"}}`, record: map[string]interface{}{"users": []map[string]interface{}{{"name": "a"}, {"name": "b"}}}, expectedOutput: "a/b"},
+		{name: "join a single value", format: `{{.name | join ", "}}`, record: map[string]interface{}{"name": "Enthaze"}, expectedOutput: "Enthaze"},
+		{name: "date", format: `{{date "2006-01-02" .created_at}}`, record: map[string]interface{}{"created_at": "2016-04-15T05:19:46 -10:00"}, expectedOutput: "2016-04-15"},
+		{name: "empty date", format: `{{date "2006-01-02" .created_at}}`, record: map[string]interface{}{"created_at": ""}, expectedOutput: ""},
+		{name: "bad date", format: `{{date "2006-01-02" .created_at}}`, record: map[string]interface{}{"created_at": "yesterday"}, expectError: true},
+		{name: "truncate", format: `{{truncate 6 .subject}}`, record: map[string]interface{}{"subject": "A Catastrophe"}, expectedOutput: "A Cat…"},
+		{name: "truncate short text", format: `{{truncate 30 .subject}}`, record: map[string]interface{}{"subject": "A Catastrophe"}, expectedOutput: "A Catastrophe"},
+		{name: "truncate a number", format: `{{truncate 2 ._id}}`, record: map[string]interface{}{"_id": float64(101)}, expectedOutput: "1…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseFormatTemplate(tt.format)
+			require.Nil(t, err)
+
+			output, err := formatTemplate(tmpl, search.DefaultSchema, search.UsersGroup, []map[string]interface{}{tt.record})
+
+			if tt.expectError {
+				require.NotNil(t, err)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, tt.expectedOutput, output)
+		})
+	}
+}
+
+func Test_parseFormatTemplate(t *testing.T) {
+	_, err := parseFormatTemplate(`{{.name}} <{{.email}}>`)
+	require.Nil(t, err)
+
+	_, err = parseFormatTemplate(`{{.name`)
+	require.NotNil(t, err)
+
+	_, err = parseFormatTemplate(`{{shout .name}}`)
+	require.NotNil(t, err)
+}
+
+func Test_formatTemplate_MissingValues(t *testing.T) {
+	tmpl, err := parseFormatTemplate(`{{.name}} ({{.organization.name}}) {{.phone}}`)
+	require.Nil(t, err)
+
+	records := []map[string]interface{}{
+		{"_id": float64(1), "name": "Francisca Rasmussen", "phone": "8335-422-718", "organization": map[string]interface{}{"_id": float64(119), "name": "Multron"}},
+		{"_id": float64(2), "name": "Woodard Burt"},
+	}
+
+	output, err := formatTemplate(tmpl, search.DefaultSchema, search.UsersGroup, records)
+	require.Nil(t, err)
+	require.Equal(t, "Francisca Rasmussen (Multron) 8335-422-718\nWoodard Burt () ", output)
+
+	//related records that weren't added have their own single relations filled in too
+	tmpl, err = parseFormatTemplate(`[{{.ticket.assigned_user.organization.name}}]`)
+	require.Nil(t, err)
+
+	output, err = formatTemplate(tmpl, search.DefaultSchema, search.TicketCommentsGroup, []map[string]interface{}{{"_id": float64(1001)}})
+	require.Nil(t, err)
+	require.Equal(t, "[]", output)
+}
+
+func Test_templateData(t *testing.T) {
+	//a schema where users point at users, so filling in missing relations has to stop
+	schema, err := search.ParseSchema([]byte(`{"users": [
+		{"name": "_id", "type": "int"},
+		{"name": "name", "type": "string"},
+		{"name": "manager_id", "type": "reference", "target": "users"}
+	]}`), search.DefaultSchema)
+	require.Nil(t, err)
+
+	data := templateData(schema, search.UsersGroup, map[string]interface{}{"_id": float64(1)})
+	require.Equal(t, map[string]interface{}{
+		"_id":        float64(1),
+		"name":       "",
+		"manager_id": "",
+		"manager":    map[string]interface{}{"_id": "", "name": "", "manager_id": ""},
+	}, data)
+
+	//relations shown as IDs are left as they are
+	data = templateData(schema, search.UsersGroup, map[string]interface{}{"_id": float64(1), "manager": float64(2)})
+	require.Equal(t, float64(2), data["manager"])
+}
@@ -0,0 +1,8 @@
+// +build !linux,!darwin
+
+package cmd
+
+//terminalSize can't find the width of the terminal on this platform, so the default width is used
+func terminalSize() int {
+	return 0
+}
@@ -0,0 +1,28 @@
+// +build linux darwin
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+type windowSize struct {
+	rows    uint16
+	columns uint16
+	xPixels uint16
+	yPixels uint16
+}
+
+//terminalSize asks the terminal on stdout how wide it is. It returns 0 if stdout isn't a terminal
+func terminalSize() int {
+	var size windowSize
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, os.Stdout.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0
+	}
+
+	return int(size.columns)
+}
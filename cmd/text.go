@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+//TextSearchCommand finds records by the words in their free text fields
+type TextSearchCommand struct {
+	cobra      *cobra.Command
+	repository *search.SearchRepository
+	findFunc   func(query string, explain bool) []map[string]interface{}
+	streamFunc func(query string, explain bool, emit search.RecordHandler) error
+	explain    bool
+	expand     expandFlags
+	output     outputFlags
+	page       pageFlags
+	count      countFlags
+
+	Formatter func([]map[string]interface{}) (string, error)
+}
+
+//newTextSearchCommand is shared by the entity types that have a text index. textFields is only used for the help text
+func newTextSearchCommand(repo *search.SearchRepository, schema search.Schema, entityName string, textFields string, findFunc func(query string, explain bool) []map[string]interface{}, streamFunc func(query string, explain bool, emit search.RecordHandler) error) *TextSearchCommand {
+	textCmd := &TextSearchCommand{
+		Formatter:  formatJSONOutput,
+		repository: repo,
+		findFunc:   findFunc,
+		streamFunc: streamFunc,
+	}
+
+	command := &cobra.Command{
+		Use:   "text [words...]",
+		Short: fmt.Sprintf("search zendesk %s by the words in their %s.", entityName, textFields),
+		Long:  fmt.Sprintf(`search zendesk %s by the words in their %s. Only %s containing every word are returned, ranked by relevance (BM25) with the score in "_score" unless --sort is used. Case, punctuation and common words like "the" are ignored.`, entityName, textFields, entityName),
+		Args: func(command *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("requires at least one word to search for")
+			}
+
+			if len(search.Tokenize(strings.Join(args, " "))) == 0 {
+				return errors.New("the search only contains words that are too common to search for")
+			}
+
+			if err := textCmd.expand.validate(entityName); err != nil {
+				return err
+			}
+
+			if err := textCmd.output.validate(); err != nil {
+				return err
+			}
+
+			if err := textCmd.page.validate(); err != nil {
+				return err
+			}
+
+			return textCmd.count.validate()
+		},
+		RunE: textCmd.RunCommand,
+	}
+
+	command.Flags().BoolVar(&textCmd.explain, "explain", false, `add an "_explain" field showing how much each word added to the score`)
+	textCmd.expand.addFlags(command, schema)
+	textCmd.output.addFlags(command, schema, entityName)
+	textCmd.output.fields = &textCmd.expand.fields
+	textCmd.output.extraColumns = []string{"_score"}
+	textCmd.page.addFlags(command, schema, entityName)
+	textCmd.page.extraFields = []string{"_score"}
+	textCmd.count.addFlags(command)
+	textCmd.cobra = command
+
+	return textCmd
+}
+
+func (tc *TextSearchCommand) RunCommand(command *cobra.Command, args []string) error {
+	tc.expand.apply(tc.repository)
+	tc.page.apply(tc.repository)
+
+	query := strings.Join(args, " ")
+
+	if tc.count.enabled() {
+		tc.count.apply(tc.repository)
+		return tc.count.report(len(tc.findFunc(query, false)))
+	}
+
+	if tc.output.streaming() {
+		return tc.streamFunc(query, tc.explain, tc.output.recordWriter(""))
+	}
+
+	searchResults := tc.findFunc(query, tc.explain)
+
+	formatter := tc.output.formatter(tc.output.entities[0], tc.Formatter)
+
+	formattedResults, err := formatter(searchResults)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(formattedResults)
+	return nil
+}
@@ -4,8 +4,10 @@ go 1.13
 
 require (
 	github.com/markbates/pkger v0.14.0
+	github.com/peterh/liner v1.2.1
 	github.com/pkg/errors v0.9.1
 	github.com/spf13/cobra v0.0.5
+	github.com/spf13/pflag v1.0.3
 	github.com/stretchr/objx v0.2.0 // indirect
 	github.com/stretchr/testify v1.4.0
 )
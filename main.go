@@ -2,74 +2,141 @@ package main
 
 import (
 	"encoding/json"
-	"log"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/markbates/pkger"
+	"github.com/pkg/errors"
 	"github.com/superjinjo/zendesk-search/cmd"
 	"github.com/superjinjo/zendesk-search/search"
 )
 
-func openJSONFile(pkgerPath string) ([]map[string]interface{}, error) {
-	file, err := pkger.Open(pkgerPath)
-	if err != nil {
-		return nil, err
+//decodeJSONRecords reads a JSON array one record at a time so that errors can point to the bad record
+func decodeJSONRecords(reader io.Reader) ([]map[string]interface{}, error) {
+	jsonDecoder := json.NewDecoder(reader)
+
+	if _, err := jsonDecoder.Token(); err != nil {
+		return nil, errors.WithMessage(err, "Expected a JSON array of records")
 	}
-	defer file.Close()
 
-	jsonDecoder := json.NewDecoder(file)
+	fileJSON := []map[string]interface{}{}
 
-	var fileJSON []map[string]interface{}
+	for i := 0; jsonDecoder.More(); i++ {
+		var record map[string]interface{}
 
-	if err := jsonDecoder.Decode(&fileJSON); err != nil {
-		return nil, err
+		if err := jsonDecoder.Decode(&record); err != nil {
+			return nil, errors.WithMessagef(err, "Error decoding record at index %d", i)
+		}
+
+		fileJSON = append(fileJSON, record)
+	}
+
+	if _, err := jsonDecoder.Token(); err != nil {
+		return nil, errors.WithMessage(err, "Expected the end of the JSON array")
 	}
 
 	return fileJSON, nil
 }
 
-func buildRepository() (*search.SearchRepository, error) {
-	//pkger requires that you use hardcoded strings with their functions
-	//in order to properly pack the files into the binary
-	pkger.Include("/data/users.json")
-	userData, err := openJSONFile("/data/users.json")
-	if err != nil {
-		return nil, err
+//openJSONFile reads the file at filePath, or the file embedded by pkger if no path was given
+func openJSONFile(filePath string, pkgerPath string) ([]map[string]interface{}, error) {
+	var file io.ReadCloser
+	var err error
+
+	if filePath != "" {
+		file, err = os.Open(filePath)
+	} else {
+		file, err = pkger.Open(pkgerPath)
 	}
 
-	userRepo, err := search.NewUserJSONRepository(userData)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 
-	pkger.Include("/data/organizations.json")
-	orgData, err := openJSONFile("/data/organizations.json")
+	fileJSON, err := decodeJSONRecords(file)
 	if err != nil {
-		return nil, err
+		return nil, errors.WithMessagef(err, "Error reading %s", sourceName(filePath, pkgerPath))
 	}
 
-	orgRepo, err := search.NewOrgJSONRepository(orgData)
-	if err != nil {
-		return nil, err
+	return fileJSON, nil
+}
+
+//dataFilePath picks the file given for a single entity first, then the file inside the data directory.
+//An empty path means the embedded file should be used
+func dataFilePath(entityFile string, dataDir string, fileName string) string {
+	if entityFile != "" {
+		return entityFile
 	}
 
-	pkger.Include("/data/tickets.json")
-	ticketData, err := openJSONFile("/data/tickets.json")
-	if err != nil {
-		return nil, err
+	if dataDir != "" {
+		return filepath.Join(dataDir, fileName)
 	}
 
-	ticketRepo, err := search.NewTicketJSONRepository(ticketData)
-	if err != nil {
-		return nil, err
+	return ""
+}
+
+//sourceName is used in error messages so that embedded files can be told apart from files on disk
+func sourceName(filePath string, pkgerPath string) string {
+	if filePath != "" {
+		return filePath
 	}
 
-	return search.NewSearchRepository(userRepo, orgRepo, ticketRepo), nil
+	return "embedded " + pkgerPath
 }
 
-func main() {
-	searchRepo, err := buildRepository()
-	if err != nil {
-		log.Fatal(err)
+//requiredEntities are the types of record a data directory has to have a file for.
+//Leaving out the file of any other type loads it without any records
+var requiredEntities = []string{search.UsersGroup, search.OrganizationsGroup, search.TicketsGroup}
+
+//openEntityFile reads the records of one type of record from the file given for it, the data directory or the embedded data
+func openEntityFile(entity string, sources cmd.DataSources) ([]map[string]interface{}, string, error) {
+	fileName := entity + ".json"
+	pkgerPath := "/data/" + fileName
+	filePath := dataFilePath(sources.Files[entity], sources.DataDir, fileName)
+	source := sourceName(filePath, pkgerPath)
+
+	required := false
+	for _, requiredEntity := range requiredEntities {
+		required = required || entity == requiredEntity
+	}
+
+	if !required && sources.Files[entity] == "" && sources.DataDir != "" {
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			return []map[string]interface{}{}, source, nil
+		}
 	}
-	cmd.Execute(searchRepo)
+
+	data, err := openJSONFile(filePath, pkgerPath)
+	return data, source, err
+}
+
+func buildRepository(sources cmd.DataSources) (*search.SearchRepository, error) {
+	//pkger requires that you use hardcoded strings with their functions
+	//in order to properly pack the files into the binary
+	pkger.Include("/data")
+
+	repositories := make(map[string]*search.EntityRepository, len(search.EntityGroups))
+
+	for _, entity := range search.EntityGroups {
+		data, source, err := openEntityFile(entity, sources)
+		if err != nil {
+			return nil, err
+		}
+
+		records, err := search.NewEntityRepository(data, sources.Schema.Entity(entity))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "Error loading %s from %s", strings.Replace(entity, "_", " ", -1), source)
+		}
+
+		repositories[entity] = records
+	}
+
+	return search.NewSearchRepositoryFromEntities(repositories), nil
+}
+
+func main() {
+	cmd.Execute(buildRepository)
 }
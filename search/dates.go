@@ -0,0 +1,204 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//DataTimeLayout is the timestamp format used in the zendesk exports, like "2016-04-28T11:19:34 -10:00"
+const DataTimeLayout = "2006-01-02T15:04:05 -07:00"
+
+//date layouts accepted in date expressions. Layouts without an offset are read in the local time zone
+var dateExpressionLayouts = []string{
+	DataTimeLayout,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006-01",
+}
+
+var relativeDatePattern = regexp.MustCompile(`^([+-])(\d+)([hdwmy])$`)
+
+//ParseDataTime parses a timestamp from the data files. RFC3339 timestamps are accepted as well
+func ParseDataTime(value string) (time.Time, error) {
+	if parsed, err := time.Parse(DataTimeLayout, value); err == nil {
+		return parsed, nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+
+	return time.Time{}, errors.Errorf(`"%v" is not a valid timestamp, expected a format like "2016-04-28T11:19:34 -10:00"`, value)
+}
+
+//ParseDateExpression reads a date typed in by a user. It accepts the data's own timestamp format, RFC3339, "2016-04-28" and "2016-04",
+//plus "now" and times relative to now like "-30d" or "+2w" (units are h, d, w, m for months and y)
+func ParseDateExpression(expression string, now time.Time) (time.Time, error) {
+	expression = strings.TrimSpace(expression)
+
+	if expression == "now" {
+		return now, nil
+	}
+
+	if parts := relativeDatePattern.FindStringSubmatch(expression); parts != nil {
+		amount, _ := strconv.Atoi(parts[2])
+		if parts[1] == "-" {
+			amount = -amount
+		}
+
+		switch parts[3] {
+		case "h":
+			return now.Add(time.Duration(amount) * time.Hour), nil
+		case "d":
+			return now.AddDate(0, 0, amount), nil
+		case "w":
+			return now.AddDate(0, 0, amount*7), nil
+		case "m":
+			return now.AddDate(0, amount, 0), nil
+		default:
+			return now.AddDate(amount, 0, 0), nil
+		}
+	}
+
+	for _, layout := range dateExpressionLayouts {
+		if parsed, err := time.ParseInLocation(layout, expression, now.Location()); err == nil {
+			return parsed, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf(`Invalid date "%v", use a date like 2016-06-01, a timestamp like 2016-04-28T11:19:34 -10:00, or a relative date like -30d`, expression)
+}
+
+//periodLayouts are the date layouts that stand for a whole period rather than an instant, with how long that period is
+var periodLayouts = []struct {
+	layout string
+	years  int
+	months int
+	days   int
+}{
+	{layout: "2006-01-02", days: 1},
+	{layout: "2006-01", months: 1},
+}
+
+//ParseDateRangeEnd reads the upper bound of a date range, see ParseDateExpression. A date like "2016-06-23" or "2016-06" takes in
+//the whole day or month, so the end returned is the start of the next one and inclusive is false. Other dates are included themselves
+func ParseDateRangeEnd(expression string, now time.Time) (end time.Time, inclusive bool, err error) {
+	for _, period := range periodLayouts {
+		if parsed, err := time.ParseInLocation(period.layout, strings.TrimSpace(expression), now.Location()); err == nil {
+			return parsed.AddDate(period.years, period.months, period.days), false, nil
+		}
+	}
+
+	end, err = ParseDateExpression(expression, now)
+	return end, true, err
+}
+
+//DateRange is a search term that matches timestamps between From and To. A zero time leaves that side open
+type DateRange struct {
+	From          time.Time
+	To            time.Time
+	FromInclusive bool
+	ToInclusive   bool
+}
+
+//Contains checks if the time is inside the range. Times are compared as instants so time zone offsets don't matter
+func (dateRange DateRange) Contains(value time.Time) bool {
+	if !dateRange.From.IsZero() {
+		if value.Before(dateRange.From) || (value.Equal(dateRange.From) && !dateRange.FromInclusive) {
+			return false
+		}
+	}
+
+	if !dateRange.To.IsZero() {
+		if value.After(dateRange.To) || (value.Equal(dateRange.To) && !dateRange.ToInclusive) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type dateEntry struct {
+	value  time.Time
+	record map[string]interface{}
+}
+
+//dateIndex parses the date fields when the data is loaded and keeps them sorted so date ranges can use a binary search
+type dateIndex struct {
+	fieldNames []string
+	fields     map[string][]dateEntry
+}
+
+func newDateIndex(fieldNames ...string) *dateIndex {
+	return &dateIndex{
+		fieldNames: fieldNames,
+		fields:     make(map[string][]dateEntry),
+	}
+}
+
+//add parses the record's date fields. Empty dates are skipped but dates that can't be parsed are an error.
+//build must be called once all the records are added
+func (index *dateIndex) add(record map[string]interface{}) error {
+	for _, fieldName := range index.fieldNames {
+		fieldValue := record[fieldName]
+		if valueIsEmpty(fieldValue) {
+			continue
+		}
+
+		dateString, isString := fieldValue.(string)
+		if !isString {
+			return errors.Errorf(`Date field "%v" is not a string`, fieldName)
+		}
+
+		parsed, err := ParseDataTime(dateString)
+		if err != nil {
+			return errors.WithMessagef(err, `Invalid date in "%v"`, fieldName)
+		}
+
+		index.fields[fieldName] = append(index.fields[fieldName], dateEntry{value: parsed, record: record})
+	}
+
+	return nil
+}
+
+func (index *dateIndex) build() {
+	for _, entries := range index.fields {
+		entries := entries
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].value.Before(entries[j].value)
+		})
+	}
+}
+
+//find returns the records with a date in range, earliest first
+func (index *dateIndex) find(fieldName string, dateRange DateRange) []map[string]interface{} {
+	entries := index.fields[fieldName]
+	results := []map[string]interface{}{}
+
+	start := 0
+	if !dateRange.From.IsZero() {
+		start = sort.Search(len(entries), func(i int) bool {
+			return !entries[i].value.Before(dateRange.From)
+		})
+	}
+
+	for i := start; i < len(entries); i++ {
+		if !dateRange.To.IsZero() && entries[i].value.After(dateRange.To) {
+			break
+		}
+
+		if dateRange.Contains(entries[i].value) {
+			results = append(results, entries[i].record)
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,212 @@
+package search_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+func Test_ParseDataTime(t *testing.T) {
+	parsed, err := search.ParseDataTime("2016-04-28T11:19:34 -10:00")
+	require.Nil(t, err)
+	require.Equal(t, time.Date(2016, 4, 28, 21, 19, 34, 0, time.UTC), parsed.UTC())
+
+	parsed2, err := search.ParseDataTime("2016-04-28T21:19:34Z")
+	require.Nil(t, err)
+	require.True(t, parsed.Equal(parsed2))
+
+	_, err = search.ParseDataTime("28/04/2016")
+	require.NotNil(t, err)
+}
+
+func Test_ParseDateExpression(t *testing.T) {
+	now := time.Date(2016, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		expression   string
+		expectedTime time.Time
+		expectError  bool
+	}{
+		{
+			name:         "data timestamp",
+			expression:   "2016-04-28T11:19:34 -10:00",
+			expectedTime: time.Date(2016, 4, 28, 21, 19, 34, 0, time.UTC),
+		},
+		{
+			name:         "date only",
+			expression:   "2016-06-01",
+			expectedTime: time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "month only",
+			expression:   "2016-06",
+			expectedTime: time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "now",
+			expression:   "now",
+			expectedTime: now,
+		},
+		{
+			name:         "days ago",
+			expression:   "-30d",
+			expectedTime: time.Date(2016, 5, 16, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "weeks from now",
+			expression:   "+2w",
+			expectedTime: time.Date(2016, 6, 29, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "months ago",
+			expression:   "-6m",
+			expectedTime: time.Date(2015, 12, 15, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "years ago",
+			expression:   "-1y",
+			expectedTime: time.Date(2015, 6, 15, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "unknown unit",
+			expression:  "-30q",
+			expectError: true,
+		},
+		{
+			name:        "not a date",
+			expression:  "June",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := search.ParseDateExpression(tt.expression, now)
+			if tt.expectError {
+				require.NotNil(t, err)
+				return
+			}
+
+			require.Nil(t, err)
+			require.True(t, tt.expectedTime.Equal(parsed), "expected %v, got %v", tt.expectedTime, parsed)
+		})
+	}
+}
+
+func Test_ParseDateRangeEnd(t *testing.T) {
+	now := time.Date(2016, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name              string
+		expression        string
+		expectedTime      time.Time
+		expectedInclusive bool
+	}{
+		{
+			name:         "date only takes in the whole day",
+			expression:   "2016-06-23",
+			expectedTime: time.Date(2016, 6, 24, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "month only takes in the whole month",
+			expression:   "2016-12",
+			expectedTime: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:              "timestamp is included itself",
+			expression:        "2016-06-23T10:00:00 -10:00",
+			expectedTime:      time.Date(2016, 6, 23, 20, 0, 0, 0, time.UTC),
+			expectedInclusive: true,
+		},
+		{
+			name:              "relative date is included itself",
+			expression:        "-1d",
+			expectedTime:      time.Date(2016, 6, 14, 12, 0, 0, 0, time.UTC),
+			expectedInclusive: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end, inclusive, err := search.ParseDateRangeEnd(tt.expression, now)
+			require.Nil(t, err)
+			require.True(t, tt.expectedTime.Equal(end), "expected %v, got %v", tt.expectedTime, end)
+			require.Equal(t, tt.expectedInclusive, inclusive)
+		})
+	}
+
+	_, _, err := search.ParseDateRangeEnd("next tuesday", now)
+	require.NotNil(t, err)
+}
+
+func Test_DateRange_WholeDayEnd(t *testing.T) {
+	ticketList := []map[string]interface{}{
+		{"_id": "start", "due_at": "2016-06-20T00:00:00Z"},
+		{"_id": "last day", "due_at": "2016-06-23T18:30:00Z"},
+		{"_id": "next day", "due_at": "2016-06-24T00:00:00Z"},
+	}
+	tickets, err := search.NewTicketJSONRepository(ticketList)
+	require.Nil(t, err)
+
+	now := time.Now().In(time.UTC)
+	from, err := search.ParseDateExpression("2016-06-20", now)
+	require.Nil(t, err)
+
+	to, inclusive, err := search.ParseDateRangeEnd("2016-06-23", now)
+	require.Nil(t, err)
+
+	//the whole of the 23rd is in range, but not the first moment of the 24th
+	between := tickets.FindByField("due_at", search.DateRange{From: from, To: to, FromInclusive: true, ToInclusive: inclusive})
+	require.Equal(t, []map[string]interface{}{ticketList[0], ticketList[1]}, between)
+}
+
+func Test_DateRange_FindByField(t *testing.T) {
+	ticketList := []map[string]interface{}{
+		{"_id": "may", "due_at": "2016-05-31T23:00:00 -10:00"},
+		{"_id": "june", "due_at": "2016-06-01T08:00:00 +10:00"},
+		{"_id": "april", "due_at": "2016-04-10T10:00:00 -10:00"},
+		{"_id": "none"},
+	}
+	tickets, err := search.NewTicketJSONRepository(ticketList)
+	require.Nil(t, err)
+
+	june := time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	//"may" is June 1st in UTC and "june" is still May 31st in UTC, so the offsets have to be taken into account
+	before := tickets.FindByField("due_at", search.DateRange{To: june})
+	require.Equal(t, []map[string]interface{}{ticketList[2], ticketList[1]}, before)
+
+	after := tickets.FindByField("due_at", search.DateRange{From: june, FromInclusive: true})
+	require.Equal(t, []map[string]interface{}{ticketList[0]}, after)
+
+	between := tickets.FindByField("due_at", search.DateRange{
+		From:          time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC),
+		To:            time.Date(2016, 4, 30, 0, 0, 0, 0, time.UTC),
+		FromInclusive: true,
+		ToInclusive:   true,
+	})
+	require.Equal(t, []map[string]interface{}{ticketList[2]}, between)
+
+	//fields that aren't dates never match
+	require.Empty(t, tickets.FindByField("subject", search.DateRange{To: june}))
+}
+
+func Test_NewTicketJSONRepository_InvalidDate(t *testing.T) {
+	_, err := search.NewTicketJSONRepository([]map[string]interface{}{
+		{"_id": "abcd", "due_at": "2016-05-31T23:00:00 -10:00"},
+		{"_id": "efgh", "due_at": "next tuesday"},
+	})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "index 1")
+	require.Contains(t, err.Error(), "due_at")
+
+	//empty dates are fine
+	_, err = search.NewTicketJSONRepository([]map[string]interface{}{
+		{"_id": "abcd", "due_at": ""},
+	})
+	require.Nil(t, err)
+}
@@ -0,0 +1,152 @@
+package search
+
+import (
+	"sort"
+	"time"
+)
+
+//types DescribeField can infer that aren't used in a schema
+const (
+	//FieldMixed is a field holding values of more than one type
+	FieldMixed FieldType = "mixed"
+	//FieldUnknown is a field that is empty or missing in every record
+	FieldUnknown FieldType = "unknown"
+)
+
+//ValueCount is how many records have one value of a field. List fields count each of their items
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+//FieldStats describes the values one field has across the records of a type.
+//Missing counts records without the field or with null, and Empty counts "" and empty lists
+type FieldStats struct {
+	Field     string       `json:"field"`
+	Type      FieldType    `json:"type"`
+	Records   int          `json:"records"`
+	Present   int          `json:"present"`
+	Empty     int          `json:"empty"`
+	Missing   int          `json:"missing"`
+	Distinct  int          `json:"distinct"`
+	TopValues []ValueCount `json:"top_values"`
+	Min       interface{}  `json:"min,omitempty"` //only set for numbers and dates
+	Max       interface{}  `json:"max,omitempty"`
+}
+
+//inferType guesses the FieldType of a single value that isn't empty
+func inferType(value interface{}) FieldType {
+	switch v := value.(type) {
+	case float64:
+		return FieldInt
+	case bool:
+		return FieldBool
+	case []interface{}:
+		return FieldStringList
+	case string:
+		if _, err := ParseDataTime(v); err == nil {
+			return FieldDateTime
+		}
+
+		return FieldString
+	default:
+		return FieldMixed
+	}
+}
+
+//DescribeField works out the stats of a field from the records. top limits how many values are listed, 0 lists them all
+func DescribeField(records []map[string]interface{}, fieldName string, top int) FieldStats {
+	stats := FieldStats{Field: fieldName, Type: FieldUnknown, Records: len(records), TopValues: []ValueCount{}}
+
+	counts := map[string]int{}
+	var numbers []float64
+	var minDate, maxDate time.Time
+	var minText, maxText string
+
+	for _, record := range records {
+		value := record[fieldName]
+
+		if value == nil {
+			stats.Missing++
+			continue
+		}
+
+		list, isList := value.([]interface{})
+		if value == "" || (isList && len(list) == 0) {
+			stats.Empty++
+			continue
+		}
+
+		stats.Present++
+
+		valueType := inferType(value)
+		if stats.Type == FieldUnknown {
+			stats.Type = valueType
+		} else if stats.Type != valueType {
+			stats.Type = FieldMixed
+		}
+
+		if !isList {
+			list = []interface{}{value}
+		}
+
+		for _, item := range list {
+			counts[stringVal(item)]++
+		}
+
+		switch valueType {
+		case FieldInt:
+			numbers = append(numbers, value.(float64))
+		case FieldDateTime:
+			text := value.(string)
+			date, _ := ParseDataTime(text)
+
+			if minText == "" || date.Before(minDate) {
+				minDate, minText = date, text
+			}
+
+			if maxText == "" || date.After(maxDate) {
+				maxDate, maxText = date, text
+			}
+		}
+	}
+
+	switch stats.Type {
+	case FieldInt:
+		sort.Float64s(numbers)
+		stats.Min, stats.Max = numbers[0], numbers[len(numbers)-1]
+	case FieldDateTime:
+		stats.Min, stats.Max = minText, maxText
+	}
+
+	stats.Distinct = len(counts)
+
+	for value, count := range counts {
+		stats.TopValues = append(stats.TopValues, ValueCount{Value: value, Count: count})
+	}
+
+	//the most common values first, ties in alphabetical order so the output doesn't change between runs
+	sort.Slice(stats.TopValues, func(i, j int) bool {
+		if stats.TopValues[i].Count != stats.TopValues[j].Count {
+			return stats.TopValues[i].Count > stats.TopValues[j].Count
+		}
+
+		return stats.TopValues[i].Value < stats.TopValues[j].Value
+	})
+
+	if top > 0 && len(stats.TopValues) > top {
+		stats.TopValues = stats.TopValues[:top]
+	}
+
+	return stats
+}
+
+//Describe works out the stats of a field over every loaded record of the type, see DescribeField
+func (repo *SearchRepository) Describe(entity string, fieldName string, top int) FieldStats {
+	var records []map[string]interface{}
+	if finder := repo.finder(entity); finder != nil {
+		records = finder.FindAll()
+	}
+
+	return DescribeField(records, fieldName, top)
+}
@@ -0,0 +1,58 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+func Test_DescribeField(t *testing.T) {
+	records := []map[string]interface{}{
+		{"_id": float64(3), "role": "admin", "tags": []interface{}{"Ohio", "Utah"}, "created_at": "2016-04-28T11:19:34 -10:00", "alias": ""},
+		{"_id": float64(1), "role": "agent", "tags": []interface{}{"Ohio"}, "created_at": "2016-01-02T09:00:00 -10:00"},
+		{"_id": float64(2), "role": "admin", "tags": []interface{}{}, "created_at": "2016-06-30T23:59:59 -10:00", "alias": "Miss Coffey"},
+		{"_id": float64(4), "role": float64(7), "tags": nil},
+	}
+
+	require.Equal(t, search.FieldStats{
+		Field: "role", Type: search.FieldMixed, Records: 4, Present: 4, Distinct: 3,
+		TopValues: []search.ValueCount{{Value: "admin", Count: 2}, {Value: "7", Count: 1}},
+	}, search.DescribeField(records, "role", 2))
+
+	//list fields count each item, and empty lists are empty rather than missing
+	tags := search.DescribeField(records, "tags", 0)
+	require.Equal(t, search.FieldStringList, tags.Type)
+	require.Equal(t, []int{2, 1, 1}, []int{tags.Present, tags.Empty, tags.Missing})
+	require.Equal(t, []search.ValueCount{{Value: "Ohio", Count: 2}, {Value: "Utah", Count: 1}}, tags.TopValues)
+
+	ids := search.DescribeField(records, "_id", 0)
+	require.Equal(t, search.FieldInt, ids.Type)
+	require.Equal(t, float64(1), ids.Min)
+	require.Equal(t, float64(4), ids.Max)
+
+	dates := search.DescribeField(records, "created_at", 1)
+	require.Equal(t, search.FieldDateTime, dates.Type)
+	require.Equal(t, "2016-01-02T09:00:00 -10:00", dates.Min)
+	require.Equal(t, "2016-06-30T23:59:59 -10:00", dates.Max)
+	require.Len(t, dates.TopValues, 1)
+
+	alias := search.DescribeField(records, "alias", 0)
+	require.Equal(t, search.FieldString, alias.Type)
+	require.Nil(t, alias.Min)
+	require.Equal(t, []int{1, 1, 2}, []int{alias.Present, alias.Empty, alias.Missing})
+
+	require.Equal(t, search.FieldUnknown, search.DescribeField(records, "phone", 0).Type)
+	require.Equal(t, search.FieldUnknown, search.DescribeField(nil, "phone", 0).Type)
+}
+
+func Test_SearchRepository_Describe(t *testing.T) {
+	repo := newRelationsTestRepository(t)
+
+	stats := repo.Describe(search.UsersGroup, "organization_id", 0)
+	require.Equal(t, 2, stats.Records)
+	require.Equal(t, 1, stats.Present)
+	require.Equal(t, []search.ValueCount{{Value: "22", Count: 1}}, stats.TopValues)
+
+	require.Equal(t, 0, repo.Describe(search.GroupsGroup, "name", 0).Records)
+}
@@ -0,0 +1,236 @@
+package search
+
+import (
+	"github.com/pkg/errors"
+)
+
+//EntityRepository stores one type of record, indexed by the field types in its schema: "_id" and every reference field
+//for lookups, numbers and dates for ranges, and the indexed fields for text searches
+type EntityRepository struct {
+	schema         EntitySchema
+	records        []map[string]interface{}                            //in the order they were loaded
+	idIndex        map[interface{}]map[string]interface{}              //map of json data indexed by ID
+	referenceIndex map[string]map[interface{}][]map[string]interface{} //map of records indexed by the ID in each reference field
+	textIndex      *textIndex                                          //words in the indexed fields
+	numericIndex   *numericIndex                                       //sorted values of the numeric fields, for range searches
+	dateIndex      *dateIndex                                          //parsed date fields, for date range searches
+	valueMatcher   ValueMatcher
+	exactMatch     bool //the ID and reference indexes only know exact values, so they are skipped when this is false
+}
+
+//NewEntityRepository loads the records of one type. IDs can be numbers or strings, as the schema says
+func NewEntityRepository(records []map[string]interface{}, schema EntitySchema) (*EntityRepository, error) {
+
+	repository := &EntityRepository{
+		schema:         schema,
+		idIndex:        make(map[interface{}]map[string]interface{}),
+		referenceIndex: make(map[string]map[interface{}][]map[string]interface{}),
+		textIndex:      newTextIndex(schema.IndexedFields()...),
+		numericIndex:   newNumericIndex(schema.FieldsOfType(FieldInt)...),
+		dateIndex:      newDateIndex(schema.FieldsOfType(FieldDateTime)...),
+		valueMatcher:   SearchValueMatches,
+		exactMatch:     true,
+	}
+
+	for _, fieldName := range schema.FieldsOfType(FieldReference, FieldReferenceList) {
+		repository.referenceIndex[fieldName] = make(map[interface{}][]map[string]interface{})
+	}
+
+	for i, record := range records {
+		if err := repository.addRecord(record); err != nil {
+			return nil, errors.WithMessagef(err, "Error with %v record at index %d", schema.Name, i)
+		}
+	}
+
+	repository.numericIndex.build()
+	repository.dateIndex.build()
+
+	return repository, nil
+}
+
+//Schema returns the schema the records were indexed with
+func (repo *EntityRepository) Schema() EntitySchema {
+	return repo.schema
+}
+
+//SetValueMatcher lets you set a different matcher which is useful for testing
+func (repo *EntityRepository) SetValueMatcher(matcherFn ValueMatcher) {
+	repo.valueMatcher = matcherFn
+}
+
+//SetExactMatch says if searches only look for equal values. When they don't, like with --match ci or --regex,
+//IDs and references are checked with the value matcher instead of being looked up in the indexes
+func (repo *EntityRepository) SetExactMatch(exact bool) {
+	repo.exactMatch = exact
+}
+
+//idKey turns an ID into the key it is indexed by. IDs typed in as text are read as numbers when the ID is an int,
+//and missing IDs become 0 or "" so records with an empty reference can be looked up too
+func idKey(keyType FieldType, id interface{}) (interface{}, bool) {
+	if keyType == FieldInt {
+		return floatVal(id)
+	}
+
+	return stringVal(id), true
+}
+
+func (repo *EntityRepository) idType() FieldType {
+	idField, _ := repo.schema.Field("_id")
+	return idField.Type
+}
+
+func (repo *EntityRepository) addRecord(record map[string]interface{}) error {
+	idType := repo.idType()
+
+	var recordID interface{}
+	switch id := record["_id"].(type) { //FYI: in go, if a map doesn't have a key, it simply returns nil
+	case float64:
+		if idType == FieldInt {
+			recordID = id
+		}
+	case string:
+		if idType == FieldString {
+			recordID = id
+		}
+	}
+
+	if recordID == nil {
+		return errors.Errorf("Record is missing \"_id\" field or \"_id\" is not %v", idType)
+	}
+
+	if _, exists := repo.idIndex[recordID]; exists {
+		return errors.Errorf("Record with ID of %v already exists", recordID)
+	}
+
+	if err := repo.dateIndex.add(record); err != nil {
+		return err
+	}
+
+	repo.records = append(repo.records, record)
+	repo.idIndex[recordID] = record
+	repo.numericIndex.add(record)
+
+	for fieldName, index := range repo.referenceIndex {
+		field, _ := repo.schema.Field(fieldName)
+
+		ids := []interface{}{record[fieldName]}
+		if field.Type == FieldReferenceList {
+			ids = sliceVal(record[fieldName])
+			if len(ids) == 0 {
+				ids = []interface{}{nil}
+			}
+		}
+
+		for _, id := range ids {
+			key, isKey := idKey(field.keyType, id)
+			if !isKey {
+				key, _ = idKey(field.keyType, nil)
+			}
+
+			index[key] = append(index[key], record)
+		}
+	}
+
+	repo.textIndex.add(record)
+
+	return nil
+}
+
+//FindByID looks up one record. Numeric IDs can be given as numbers or as text
+func (repo *EntityRepository) FindByID(id interface{}) map[string]interface{} {
+	key, isKey := idKey(repo.idType(), id)
+	if !isKey {
+		return nil
+	}
+
+	return repo.idIndex[key]
+}
+
+//FindByReference finds the records with the ID in the reference field. An empty ID finds the records without a reference
+func (repo *EntityRepository) FindByReference(fieldName string, id interface{}) []map[string]interface{} {
+	index, isReference := repo.referenceIndex[fieldName]
+	if !isReference {
+		return []map[string]interface{}{}
+	}
+
+	field, _ := repo.schema.Field(fieldName)
+
+	key, isKey := idKey(field.keyType, id)
+	if !isKey {
+		return []map[string]interface{}{}
+	}
+
+	return append([]map[string]interface{}{}, index[key]...)
+}
+
+//FindByText finds the records with every word of the query in their indexed fields, ranked by relevance
+func (repo *EntityRepository) FindByText(query string) []TextMatch {
+	return repo.textIndex.search(query)
+}
+
+//FindAll returns every record in the order they were loaded
+func (repo *EntityRepository) FindAll() []map[string]interface{} {
+	return append([]map[string]interface{}{}, repo.records...)
+}
+
+//typedTerm turns a search term into the type the schema gives the field. Text is parsed for number and true/false fields,
+//and numbers are written out for text fields. A term that can't be the field's type can't match anything
+func (repo *EntityRepository) typedTerm(fieldName string, searchVal interface{}) (interface{}, bool) {
+	field, inSchema := repo.schema.Field(fieldName)
+	if !inSchema {
+		return searchVal, true
+	}
+
+	switch term := searchVal.(type) {
+	case string:
+		value, err := field.ParseTerm(term)
+		return value, err == nil
+	case float64, int, bool:
+		if valueType := field.valueType(); valueType != FieldInt && valueType != FieldBool {
+			return stringVal(term), true
+		}
+	}
+
+	return searchVal, true
+}
+
+//FindByField finds the records where the field matches the search term, which is read as the type the schema gives the field
+func (repo *EntityRepository) FindByField(fieldName string, searchVal interface{}) []map[string]interface{} {
+	searchVal, isValid := repo.typedTerm(fieldName, searchVal)
+	if !isValid {
+		return []map[string]interface{}{}
+	}
+
+	if numRange, isRange := searchVal.(NumericRange); isRange {
+		return repo.numericIndex.find(fieldName, numRange)
+	}
+
+	if dateRange, isDateRange := searchVal.(DateRange); isDateRange {
+		return repo.dateIndex.find(fieldName, dateRange)
+	}
+
+	//the indexes only know exact values, so searches by case, prefix, pattern and so on check every record
+	if fieldName == "_id" && repo.exactMatch {
+		recordList := []map[string]interface{}{}
+
+		if record := repo.FindByID(searchVal); record != nil {
+			recordList = append(recordList, record)
+		}
+
+		return recordList
+	}
+
+	if _, isReference := repo.referenceIndex[fieldName]; isReference && repo.exactMatch {
+		return repo.FindByReference(fieldName, searchVal)
+	}
+
+	recordList := []map[string]interface{}{}
+
+	for _, record := range repo.records {
+		if repo.valueMatcher(record[fieldName], searchVal) {
+			recordList = append(recordList, record)
+		}
+	}
+
+	return recordList
+}
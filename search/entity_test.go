@@ -0,0 +1,125 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+//brandSchema has string IDs and a reference to a numeric ID, the opposite of the users
+func brandSchema(t *testing.T) search.EntitySchema {
+	schema, err := search.ParseSchema([]byte(`{"brands": [
+		{"name": "_id", "type": "string"},
+		{"name": "name", "type": "string", "indexed": true},
+		{"name": "owner_id", "type": "reference", "target": "users"}
+	]}`), search.DefaultSchema)
+	require.Nil(t, err)
+
+	return schema.Entity("brands")
+}
+
+func Test_EntityRepository(t *testing.T) {
+	brandList := []map[string]interface{}{
+		{"_id": "b3", "name": "Zendesk Support", "owner_id": float64(71)},
+		{"_id": "b1", "name": "Zendesk Chat", "owner_id": float64(71)},
+		{"_id": "b2", "name": "Guide"},
+	}
+
+	brands, err := search.NewEntityRepository(brandList, brandSchema(t))
+	require.Nil(t, err)
+
+	require.Equal(t, brandList[1], brands.FindByID("b1"))
+	require.Nil(t, brands.FindByID("b404"))
+
+	require.Equal(t, []map[string]interface{}{brandList[0], brandList[1]}, brands.FindByReference("owner_id", float64(71)))
+	require.Equal(t, []map[string]interface{}{brandList[0], brandList[1]}, brands.FindByField("owner_id", "71"))
+	require.Equal(t, []map[string]interface{}{brandList[2]}, brands.FindByField("owner_id", ""))
+	require.Empty(t, brands.FindByReference("name", "Guide"))
+
+	require.Equal(t, []map[string]interface{}{brandList[2]}, brands.FindByField("name", "Guide"))
+	require.Len(t, brands.FindByText("zendesk"), 2)
+
+	//FindAll keeps the order of the data
+	require.Equal(t, brandList, brands.FindAll())
+}
+
+func Test_EntityRepository_InvalidRecords(t *testing.T) {
+	tests := []struct {
+		name   string
+		brands []map[string]interface{}
+	}{
+		{
+			name:   "missing ID",
+			brands: []map[string]interface{}{{"name": "Guide"}},
+		},
+		{
+			name:   "ID of the wrong type",
+			brands: []map[string]interface{}{{"_id": float64(1), "name": "Guide"}},
+		},
+		{
+			name:   "ID used twice",
+			brands: []map[string]interface{}{{"_id": "b1"}, {"_id": "b1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := search.NewEntityRepository(tt.brands, brandSchema(t))
+			require.NotNil(t, err)
+		})
+	}
+}
+
+func Test_EntityRepository_MatchModesSkipIndexes(t *testing.T) {
+	tickets, err := search.NewTicketJSONRepository([]map[string]interface{}{
+		{"_id": "436bf9b0-1147-4c0a-8439-6f79833bff5b", "subject": "A Catastrophe in Korea (North)"},
+		{"_id": "1a227508-9f39-427c-8f57-1b72f3fab87c", "subject": "A Catastrophe in Micronesia"},
+	})
+	require.Nil(t, err)
+
+	comments, err := search.NewEntityRepository([]map[string]interface{}{
+		{"_id": float64(10), "ticket_id": "436bf9b0-1147-4c0a-8439-6f79833bff5b"},
+		{"_id": float64(11), "ticket_id": "1a227508-9f39-427c-8f57-1b72f3fab87c"},
+	}, search.DefaultSchema.Entity(search.TicketCommentsGroup))
+	require.Nil(t, err)
+
+	require.Empty(t, tickets.FindByField("_id", "436BF9B0-1147-4C0A-8439-6F79833BFF5B"))
+
+	tickets.SetValueMatcher(search.CaselessValueMatches)
+	tickets.SetExactMatch(false)
+	require.Len(t, tickets.FindByField("_id", "436BF9B0-1147-4C0A-8439-6F79833BFF5B"), 1)
+
+	comments.SetValueMatcher(search.PrefixValueMatches)
+	comments.SetExactMatch(false)
+	found := comments.FindByField("ticket_id", "1A22")
+	require.Len(t, found, 1)
+	require.Equal(t, float64(11), found[0]["_id"])
+
+	//going back to exact matching uses the indexes again
+	comments.SetExactMatch(true)
+	require.Empty(t, comments.FindByField("ticket_id", "1A22"))
+}
+
+func Test_SearchRepository_SetMatchMode(t *testing.T) {
+	users, err := search.NewUserJSONRepository([]map[string]interface{}{})
+	require.Nil(t, err)
+
+	orgs, err := search.NewOrgJSONRepository([]map[string]interface{}{})
+	require.Nil(t, err)
+
+	tickets, err := search.NewTicketJSONRepository([]map[string]interface{}{
+		{"_id": "436bf9b0-1147-4c0a-8439-6f79833bff5b", "subject": "A Catastrophe in Korea (North)"},
+	})
+	require.Nil(t, err)
+
+	repo := search.NewSearchRepository(users, orgs, tickets)
+
+	require.Nil(t, repo.SetMatchMode(search.MatchPrefix))
+	require.Len(t, repo.Find(search.TicketsGroup, "_id", "436BF9B0"), 1)
+
+	require.Nil(t, repo.SetMatchMode(search.MatchExact))
+	require.Empty(t, repo.Find(search.TicketsGroup, "_id", "436BF9B0"))
+
+	require.NotNil(t, repo.SetMatchMode("fuzzy"))
+}
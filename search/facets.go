@@ -0,0 +1,142 @@
+package search
+
+import (
+	"sort"
+	"strings"
+)
+
+//Bucket is one combination of values of the grouped fields and how many records have it. Values are in the order of the fields
+type Bucket struct {
+	Values []interface{}
+	Count  int
+}
+
+//pathValues follows a path like organization.name through the related records added to a result.
+//Lists are flattened so that every tag or every related ticket gives its own value
+func pathValues(value interface{}, path []string) []interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return []interface{}{v}
+		}
+
+		return pathValues(v[path[0]], path[1:])
+	case []map[string]interface{}:
+		var values []interface{}
+		for _, record := range v {
+			values = append(values, pathValues(record, path)...)
+		}
+
+		return values
+	case []interface{}:
+		var values []interface{}
+		for _, item := range v {
+			values = append(values, pathValues(item, path)...)
+		}
+
+		return values
+	default:
+		if len(path) > 0 {
+			return nil
+		}
+
+		return []interface{}{value}
+	}
+}
+
+//fieldValues are the values a record is grouped by for one field. Records without a value are grouped under nil
+func fieldValues(record map[string]interface{}, field string) []interface{} {
+	var values []interface{}
+	for _, value := range pathValues(record, strings.Split(field, ".")) {
+		if value != nil && value != "" {
+			values = append(values, value)
+		}
+	}
+
+	if len(values) == 0 {
+		return []interface{}{nil}
+	}
+
+	return values
+}
+
+//combinations lists every combination of one value of each field, so a ticket with two tags is in two buckets
+func combinations(record map[string]interface{}, fields []string) [][]interface{} {
+	combined := [][]interface{}{{}}
+
+	for _, field := range fields {
+		var next [][]interface{}
+		for _, values := range combined {
+			for _, value := range fieldValues(record, field) {
+				next = append(next, append(append([]interface{}{}, values...), value))
+			}
+		}
+
+		combined = next
+	}
+
+	return combined
+}
+
+func bucketKey(values []interface{}) string {
+	keys := make([]string, len(values))
+	for i, value := range values {
+		keys[i] = stringVal(value)
+	}
+
+	return strings.Join(keys, "\x00")
+}
+
+//GroupRecords counts the records by the values of the fields. Fields of related records can be given like organization.name
+//as long as the records have those relations added. List fields like tags put a record in one bucket per item, but a record
+//is only counted once in each bucket. Buckets are ordered by count, largest first, with records missing a value last among equals
+func GroupRecords(records []map[string]interface{}, fields []string) []Bucket {
+	buckets := []Bucket{}
+	bucketIndex := map[string]int{}
+
+	for _, record := range records {
+		seen := map[string]bool{}
+
+		for _, values := range combinations(record, fields) {
+			key := bucketKey(values)
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+
+			if i, exists := bucketIndex[key]; exists {
+				buckets[i].Count++
+				continue
+			}
+
+			bucketIndex[key] = len(buckets)
+			buckets = append(buckets, Bucket{Values: values, Count: 1})
+		}
+	}
+
+	sort.SliceStable(buckets, func(i, j int) bool {
+		if buckets[i].Count != buckets[j].Count {
+			return buckets[i].Count > buckets[j].Count
+		}
+
+		for k := range fields {
+			left, right := buckets[i].Values[k], buckets[j].Values[k]
+			if left == nil || right == nil {
+				if (left == nil) == (right == nil) {
+					continue
+				}
+
+				return right == nil
+			}
+
+			if comparison := compareValues(left, right); comparison != 0 {
+				return comparison < 0
+			}
+		}
+
+		return false
+	})
+
+	return buckets
+}
@@ -0,0 +1,46 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+func Test_GroupRecords(t *testing.T) {
+	tickets := []map[string]interface{}{
+		{"priority": "high", "tags": []interface{}{"Ohio", "Utah"}, "organization": map[string]interface{}{"name": "Enthaze"}},
+		{"priority": "low", "tags": []interface{}{"Ohio", "Ohio"}, "organization": map[string]interface{}{"name": "Enthaze"}},
+		{"priority": "high", "tags": []interface{}{}},
+	}
+
+	require.Equal(t, []search.Bucket{
+		{Values: []interface{}{"high"}, Count: 2},
+		{Values: []interface{}{"low"}, Count: 1},
+	}, search.GroupRecords(tickets, []string{"priority"}))
+
+	//every tag gets its own bucket, but a record is only counted once in each
+	require.Equal(t, []search.Bucket{
+		{Values: []interface{}{"Ohio"}, Count: 2},
+		{Values: []interface{}{"Utah"}, Count: 1},
+		{Values: []interface{}{nil}, Count: 1},
+	}, search.GroupRecords(tickets, []string{"tags"}))
+
+	require.Equal(t, []search.Bucket{
+		{Values: []interface{}{"Enthaze", "high"}, Count: 1},
+		{Values: []interface{}{"Enthaze", "low"}, Count: 1},
+		{Values: []interface{}{nil, "high"}, Count: 1},
+	}, search.GroupRecords(tickets, []string{"organization.name", "priority"}))
+
+	require.Equal(t, []search.Bucket{}, search.GroupRecords(nil, []string{"priority"}))
+}
+
+func Test_GroupRecords_ManyRelations(t *testing.T) {
+	repo := newRelationsTestRepository(t)
+	repo.SetExpandOptions(search.ExpandOptions{Fields: []string{"assigned_tickets.subject"}, Depth: 1})
+
+	require.Equal(t, []search.Bucket{
+		{Values: []interface{}{"ticket 1"}, Count: 1},
+		{Values: []interface{}{nil}, Count: 1},
+	}, search.GroupRecords(repo.Find(search.UsersGroup, "_id", search.NumericRange{Min: 1, Max: 2, MinInclusive: true, MaxInclusive: true}), []string{"assigned_tickets.subject"}))
+}
@@ -0,0 +1,75 @@
+package search
+
+import (
+	"sort"
+)
+
+//The names results are grouped by when more than one type of record is searched
+const (
+	UsersGroup               = "users"
+	OrganizationsGroup       = "organizations"
+	TicketsGroup             = "tickets"
+	GroupsGroup              = "groups"
+	TicketCommentsGroup      = "ticket_comments"
+	SatisfactionRatingsGroup = "satisfaction_ratings"
+)
+
+//EntityGroups lists every type of record in the order they are shown
+var EntityGroups = []string{UsersGroup, OrganizationsGroup, TicketsGroup, GroupsGroup, TicketCommentsGroup, SatisfactionRatingsGroup}
+
+//matchingFields lists the fields of the record that match the term, in alphabetical order.
+//If fieldNames is empty every field of the record is checked
+func matchingFields(record map[string]interface{}, term interface{}, fieldNames []string, matcher ValueMatcher) []string {
+	matched := []string{}
+
+	if len(fieldNames) == 0 {
+		for fieldName := range record {
+			fieldNames = append(fieldNames, fieldName)
+		}
+		sort.Strings(fieldNames)
+	}
+
+	for _, fieldName := range fieldNames {
+		fieldValue, hasField := record[fieldName]
+		if hasField && matcher(fieldValue, term) {
+			matched = append(matched, fieldName)
+		}
+	}
+
+	return matched
+}
+
+//findInAllFields copies every record with a matching field and adds a "_matched_fields" list to the copy
+func findInAllFields(records []map[string]interface{}, term interface{}, fieldNames []string, matcher ValueMatcher) []map[string]interface{} {
+	results := []map[string]interface{}{}
+
+	for _, record := range records {
+		matched := matchingFields(record, term, fieldNames, matcher)
+		if len(matched) == 0 {
+			continue
+		}
+
+		result := make(map[string]interface{}, len(record)+1)
+		for key, value := range record {
+			result[key] = value
+		}
+		result["_matched_fields"] = matched
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+//FindEverywhere searches every field of every type of record that was loaded, or only the given fields if there are any.
+//Results are grouped by type and aren't given their related records, which keeps the output short.
+//The page options are used for each type separately
+func (repo *SearchRepository) FindEverywhere(term interface{}, fieldNames []string) map[string][]map[string]interface{} {
+	results := map[string][]map[string]interface{}{}
+
+	for _, entity := range repo.Entities() {
+		results[entity] = repo.page(entity, findInAllFields(repo.finder(entity).FindAll(), term, fieldNames, repo.valueMatcher), DefaultSort)
+	}
+
+	return results
+}
@@ -0,0 +1,50 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+func Test_SearchRepository_FindEverywhere(t *testing.T) {
+	users, err := search.NewUserJSONRepository([]map[string]interface{}{
+		{"_id": float64(1), "name": "Francisca Rasmussen", "signature": "Enthaze forever"},
+		{"_id": float64(2), "name": "Cross Barlow"},
+	})
+	require.Nil(t, err)
+
+	orgs, err := search.NewOrgJSONRepository([]map[string]interface{}{
+		{"_id": float64(101), "name": "Enthaze", "details": "MegaCorp", "tags": []interface{}{"Fulton", "West"}},
+	})
+	require.Nil(t, err)
+
+	tickets, err := search.NewTicketJSONRepository([]map[string]interface{}{
+		{"_id": "abcd", "subject": "A Catastrophe in Korea (North)", "description": "Sent by enthaze staff"},
+	})
+	require.Nil(t, err)
+
+	repo := search.NewSearchRepository(users, orgs, tickets)
+	repo.SetValueMatcher(search.ContainsValueMatches)
+
+	results := repo.FindEverywhere("enthaze", nil)
+	require.Equal(t, []map[string]interface{}{
+		{"_id": float64(1), "name": "Francisca Rasmussen", "signature": "Enthaze forever", "_matched_fields": []string{"signature"}},
+	}, results[search.UsersGroup])
+	require.Len(t, results[search.OrganizationsGroup], 1)
+	require.Equal(t, []string{"name"}, results[search.OrganizationsGroup][0]["_matched_fields"])
+	require.Len(t, results[search.TicketsGroup], 1)
+	require.Equal(t, []string{"description"}, results[search.TicketsGroup][0]["_matched_fields"])
+
+	//slice fields are searched too
+	require.Equal(t, []string{"tags"}, repo.FindEverywhere("fulton", nil)[search.OrganizationsGroup][0]["_matched_fields"])
+
+	//restricting the fields
+	restricted := repo.FindEverywhere("enthaze", []string{"name"})
+	require.Empty(t, restricted[search.UsersGroup])
+	require.Len(t, restricted[search.OrganizationsGroup], 1)
+	require.Empty(t, restricted[search.TicketsGroup])
+
+	//the stored records are not changed
+	require.NotContains(t, orgs.FindByID(101), "_matched_fields")
+}
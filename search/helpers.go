@@ -89,7 +89,7 @@ func stringVal(value interface{}) string {
 }
 
 //subjectSlice must have ALL values in term slice unless the term  slice is empty, in which case they must be equal
-func sliceContains(subjectSlice []interface{}, termSlice []interface{}) bool {
+func sliceContains(subjectSlice []interface{}, termSlice []interface{}, compare stringComparer) bool {
 	if len(termSlice) == 0 {
 		return len(subjectSlice) == 0
 	}
@@ -101,7 +101,7 @@ func sliceContains(subjectSlice []interface{}, termSlice []interface{}) bool {
 	for _, termVal := range termSlice {
 		hasVal := false
 		for _, subjectVal := range subjectSlice {
-			hasVal = SearchValueMatches(subjectVal, termVal)
+			hasVal = valueMatches(subjectVal, termVal, compare)
 
 			if hasVal {
 				break
@@ -128,13 +128,24 @@ func valueIsEmpty(value interface{}) bool {
 //ValueMatcher functions dictate the rules for deciding if two values match
 type ValueMatcher func(subject interface{}, term interface{}) bool
 
+//stringComparer functions decide if a string field matches a string term. Only strings change between match modes
+type stringComparer func(subject string, term string) bool
+
+func stringsEqual(subject string, term string) bool {
+	return subject == term
+}
+
 //empty values will count as equivalent if the field value is nil
 //nil or empty string will count as equivalent is field is an empty slice
 //a term slice is considered a match for a subject slice if the subject contains all items from the term (order doesn't matter)
 func SearchValueMatches(subject interface{}, term interface{}) bool {
+	return valueMatches(subject, term, stringsEqual)
+}
+
+func valueMatches(subject interface{}, term interface{}, compare stringComparer) bool {
 	switch subjVal := subject.(type) {
 	case int:
-		return SearchValueMatches(float64(subjVal), term)
+		return valueMatches(float64(subjVal), term, compare)
 	case float64:
 		termVal, isFloat := floatVal(term)
 		if isFloat {
@@ -150,9 +161,9 @@ func SearchValueMatches(subject interface{}, term interface{}) bool {
 
 		return false
 	case []interface{}:
-		return sliceContains(subjVal, sliceVal(term))
+		return sliceContains(subjVal, sliceVal(term), compare)
 	case string:
-		return stringVal(term) == subjVal
+		return compare(subjVal, stringVal(term))
 	default:
 		if subjVal == nil {
 			return valueIsEmpty(term)
@@ -161,3 +172,49 @@ func SearchValueMatches(subject interface{}, term interface{}) bool {
 		return subject == term
 	}
 }
+
+//copyValue deep copies the maps and slices in a record value. Anything else is immutable so it is returned as is
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return copyRecord(v)
+	case []map[string]interface{}:
+		return copyRecords(v)
+	case []interface{}:
+		valueCopy := make([]interface{}, len(v))
+		for i, item := range v {
+			valueCopy[i] = copyValue(item)
+		}
+
+		return valueCopy
+	default:
+		return value
+	}
+}
+
+//copyRecord deep copies a record so results can be changed without touching the stored data
+func copyRecord(record map[string]interface{}) map[string]interface{} {
+	if record == nil {
+		return nil
+	}
+
+	recordCopy := make(map[string]interface{}, len(record))
+	for key, value := range record {
+		recordCopy[key] = copyValue(value)
+	}
+
+	return recordCopy
+}
+
+func copyRecords(records []map[string]interface{}) []map[string]interface{} {
+	if records == nil {
+		return nil
+	}
+
+	recordsCopy := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		recordsCopy[i] = copyRecord(record)
+	}
+
+	return recordsCopy
+}
@@ -0,0 +1,138 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//Match modes change how string fields (and each item of a slice field) are compared to the search term.
+//Numbers and booleans are always compared by value
+const (
+	MatchExact    = "exact"
+	MatchCaseless = "ci"
+	MatchContains = "contains"
+	MatchPrefix   = "prefix"
+	MatchGlob     = "glob"
+)
+
+//MatchModes lists the modes accepted by MatcherForMode
+var MatchModes = []string{MatchExact, MatchCaseless, MatchContains, MatchPrefix, MatchGlob}
+
+//MatcherForMode returns the ValueMatcher for one of the MatchModes
+func MatcherForMode(mode string) (ValueMatcher, error) {
+	switch mode {
+	case MatchExact, "":
+		return SearchValueMatches, nil
+	case MatchCaseless:
+		return CaselessValueMatches, nil
+	case MatchContains:
+		return ContainsValueMatches, nil
+	case MatchPrefix:
+		return PrefixValueMatches, nil
+	case MatchGlob:
+		return GlobValueMatches, nil
+	default:
+		return nil, fmt.Errorf(`Invalid match mode "%v", must be one of %v`, mode, strings.Join(MatchModes, ", "))
+	}
+}
+
+//an empty term still means "find the empty fields" in every mode, otherwise it would match everything
+func emptyTermGuard(compare stringComparer) stringComparer {
+	return func(subject string, term string) bool {
+		if term == "" {
+			return subject == ""
+		}
+
+		return compare(subject, term)
+	}
+}
+
+var (
+	caselessEqual = emptyTermGuard(strings.EqualFold)
+
+	caselessContains = emptyTermGuard(func(subject string, term string) bool {
+		return strings.Contains(strings.ToLower(subject), strings.ToLower(term))
+	})
+
+	caselessPrefix = emptyTermGuard(func(subject string, term string) bool {
+		return strings.HasPrefix(strings.ToLower(subject), strings.ToLower(term))
+	})
+
+	caselessGlob = emptyTermGuard(func(subject string, term string) bool {
+		return globMatch([]rune(strings.ToLower(subject)), []rune(strings.ToLower(term)))
+	})
+)
+
+//CaselessValueMatches works like SearchValueMatches but ignores case for strings
+func CaselessValueMatches(subject interface{}, term interface{}) bool {
+	return valueMatches(subject, term, caselessEqual)
+}
+
+//ContainsValueMatches matches strings that contain the term anywhere, ignoring case
+func ContainsValueMatches(subject interface{}, term interface{}) bool {
+	return valueMatches(subject, term, caselessContains)
+}
+
+//PrefixValueMatches matches strings that start with the term, ignoring case
+func PrefixValueMatches(subject interface{}, term interface{}) bool {
+	return valueMatches(subject, term, caselessPrefix)
+}
+
+//GlobValueMatches treats the term as a pattern where * matches any run of characters and ? matches one character. Case is ignored
+func GlobValueMatches(subject interface{}, term interface{}) bool {
+	return valueMatches(subject, term, caselessGlob)
+}
+
+//globMatch doesn't use path.Match because that gives slashes special meaning, and tags like "Hartsville/Hartley" have them
+func globMatch(subject []rune, pattern []rune) bool {
+	subjIdx, patternIdx := 0, 0
+	starIdx, starSubjIdx := -1, 0
+
+	for subjIdx < len(subject) {
+		switch {
+		case patternIdx < len(pattern) && (pattern[patternIdx] == '?' || pattern[patternIdx] == subject[subjIdx]):
+			subjIdx++
+			patternIdx++
+		case patternIdx < len(pattern) && pattern[patternIdx] == '*':
+			starIdx = patternIdx
+			starSubjIdx = subjIdx
+			patternIdx++
+		case starIdx >= 0:
+			//backtrack and let the last star swallow one more character
+			patternIdx = starIdx + 1
+			starSubjIdx++
+			subjIdx = starSubjIdx
+		default:
+			return false
+		}
+	}
+
+	for patternIdx < len(pattern) && pattern[patternIdx] == '*' {
+		patternIdx++
+	}
+
+	return patternIdx == len(pattern)
+}
+
+//RegexpMatcher returns a matcher that ignores the term it is given and checks string fields,
+//or any item of a slice field, against the already compiled pattern instead
+func RegexpMatcher(pattern *regexp.Regexp) ValueMatcher {
+	return func(subject interface{}, term interface{}) bool {
+		switch subjVal := subject.(type) {
+		case string:
+			return pattern.MatchString(subjVal)
+		case []interface{}:
+			for _, item := range subjVal {
+				if pattern.MatchString(stringVal(item)) {
+					return true
+				}
+			}
+
+			return false
+		default:
+			//missing fields count as empty strings so that patterns like ^$ can find them
+			return subjVal == nil && pattern.MatchString("")
+		}
+	}
+}
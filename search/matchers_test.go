@@ -0,0 +1,221 @@
+package search_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+func Test_MatcherForMode(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           string
+		subject        interface{}
+		term           interface{}
+		expectedResult bool
+	}{
+		{
+			name:           "exact is case sensitive",
+			mode:           search.MatchExact,
+			subject:        "Francisca Rasmussen",
+			term:           "francisca rasmussen",
+			expectedResult: false,
+		},
+		{
+			name:           "ci ignores case",
+			mode:           search.MatchCaseless,
+			subject:        "Francisca Rasmussen",
+			term:           "francisca rasmussen",
+			expectedResult: true,
+		},
+		{
+			name:           "ci still needs the whole string",
+			mode:           search.MatchCaseless,
+			subject:        "Francisca Rasmussen",
+			term:           "francisca",
+			expectedResult: false,
+		},
+		{
+			name:           "contains finds part of a string",
+			mode:           search.MatchContains,
+			subject:        "Francisca Rasmussen",
+			term:           "rasmus",
+			expectedResult: true,
+		},
+		{
+			name:           "prefix matches the start",
+			mode:           search.MatchPrefix,
+			subject:        "Francisca Rasmussen",
+			term:           "fran",
+			expectedResult: true,
+		},
+		{
+			name:           "prefix doesn't match the middle",
+			mode:           search.MatchPrefix,
+			subject:        "Francisca Rasmussen",
+			term:           "rasmus",
+			expectedResult: false,
+		},
+		{
+			name:           "glob with star",
+			mode:           search.MatchGlob,
+			subject:        "coffeyrasmussen@flotonic.com",
+			term:           "*@flotonic.com",
+			expectedResult: true,
+		},
+		{
+			name:           "glob with question mark",
+			mode:           search.MatchGlob,
+			subject:        "en-AU",
+			term:           "en-??",
+			expectedResult: true,
+		},
+		{
+			name:           "glob treats slashes like any other character",
+			mode:           search.MatchGlob,
+			subject:        "Hartsville/Hartley",
+			term:           "hartsville*",
+			expectedResult: true,
+		},
+		{
+			name:           "glob must match the whole string",
+			mode:           search.MatchGlob,
+			subject:        "coffeyrasmussen@flotonic.com",
+			term:           "*@flotonic",
+			expectedResult: false,
+		},
+		{
+			name:           "empty term only matches empty strings",
+			mode:           search.MatchContains,
+			subject:        "Francisca Rasmussen",
+			term:           "",
+			expectedResult: false,
+		},
+		{
+			name:           "slice items use the same mode",
+			mode:           search.MatchContains,
+			subject:        []interface{}{"Springville", "Sutton"},
+			term:           "spring",
+			expectedResult: true,
+		},
+		{
+			name:           "every term item must match a slice item",
+			mode:           search.MatchPrefix,
+			subject:        []interface{}{"Springville", "Sutton"},
+			term:           "spr,wes",
+			expectedResult: false,
+		},
+		{
+			name:           "numbers still compare by value",
+			mode:           search.MatchContains,
+			subject:        float64(123),
+			term:           "12",
+			expectedResult: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := search.MatcherForMode(tt.mode)
+			require.Nil(t, err)
+			require.Equal(t, tt.expectedResult, matcher(tt.subject, tt.term))
+		})
+	}
+
+	_, err := search.MatcherForMode("fuzzy")
+	require.NotNil(t, err)
+}
+
+func Test_RegexpMatcher(t *testing.T) {
+	matcher := search.RegexpMatcher(regexp.MustCompile(`@flotonic\.com$`))
+
+	require.True(t, matcher("coffeyrasmussen@flotonic.com", nil))
+	require.False(t, matcher("jonesrasmussen@gmail.com", nil))
+
+	//the term is ignored because the pattern was compiled up front
+	require.True(t, matcher("coffeyrasmussen@flotonic.com", "something else"))
+
+	//any item in a slice can match
+	require.True(t, matcher([]interface{}{"kage.com", "sales@flotonic.com"}, nil))
+	require.False(t, matcher([]interface{}{"kage.com", "ecratic.com"}, nil))
+
+	//numbers and booleans are not matched against
+	require.False(t, search.RegexpMatcher(regexp.MustCompile(`1`))(float64(1), nil))
+
+	//missing fields are treated as empty strings
+	require.True(t, search.RegexpMatcher(regexp.MustCompile(`^$`))(nil, nil))
+
+	subjects := search.RegexpMatcher(regexp.MustCompile(`^A (Catastrophe|Nuisance) in`))
+	require.True(t, subjects("A Nuisance in Kiribati", nil))
+	require.False(t, subjects("A Problem in Malawi", nil))
+}
+
+func Test_SearchRepository_SetValueMatcher(t *testing.T) {
+	users, err := search.NewUserJSONRepository([]map[string]interface{}{
+		{"_id": float64(1), "name": "Francisca Rasmussen"},
+	})
+	require.Nil(t, err)
+
+	orgs, err := search.NewOrgJSONRepository([]map[string]interface{}{
+		{"_id": float64(101), "name": "Enthaze"},
+	})
+	require.Nil(t, err)
+
+	tickets, err := search.NewTicketJSONRepository([]map[string]interface{}{
+		{"_id": "abcd", "subject": "A Catastrophe in Korea (North)"},
+	})
+	require.Nil(t, err)
+
+	repo := search.NewSearchRepository(users, orgs, tickets)
+
+	require.Len(t, repo.Find(search.UsersGroup, "name", "francisca"), 0)
+
+	repo.SetValueMatcher(search.ContainsValueMatches)
+
+	require.Len(t, repo.Find(search.UsersGroup, "name", "francisca"), 1)
+	require.Len(t, repo.Find(search.OrganizationsGroup, "name", "enth"), 1)
+	require.Len(t, repo.Find(search.TicketsGroup, "subject", "korea"), 1)
+}
+
+func Test_RegexpMatcher_StringIDs(t *testing.T) {
+	tickets, err := search.NewTicketJSONRepository([]map[string]interface{}{
+		{"_id": "436bf9b0-1147-4c0a-8439-6f79833bff5b", "subject": "A Catastrophe in Korea (North)"},
+		{"_id": "1a227508-9f39-427c-8f57-1b72f3fab87c", "subject": "A Catastrophe in Micronesia"},
+	})
+	require.Nil(t, err)
+
+	pattern := `^436bf9b0`
+	tickets.SetValueMatcher(search.RegexpMatcher(regexp.MustCompile(pattern)))
+	tickets.SetExactMatch(false)
+
+	found := tickets.FindByField("_id", pattern)
+	require.Len(t, found, 1)
+	require.Equal(t, "436bf9b0-1147-4c0a-8439-6f79833bff5b", found[0]["_id"])
+}
+
+func Test_RegexpMatcher_NumericReferences(t *testing.T) {
+	users, err := search.NewUserJSONRepository([]map[string]interface{}{
+		{"_id": float64(1), "organization_id": float64(119)},
+		{"_id": float64(16)},
+	})
+	require.Nil(t, err)
+
+	tickets, err := search.NewTicketJSONRepository([]map[string]interface{}{
+		{"_id": "abcd", "assignee_id": float64(24)},
+		{"_id": "efgh"},
+	})
+	require.Nil(t, err)
+
+	//the pattern is checked against every record rather than looked up as an ID, so records without a reference don't match
+	pattern := `^1`
+	users.SetValueMatcher(search.RegexpMatcher(regexp.MustCompile(pattern)))
+	users.SetExactMatch(false)
+	tickets.SetValueMatcher(search.RegexpMatcher(regexp.MustCompile(pattern)))
+	tickets.SetExactMatch(false)
+
+	require.Empty(t, users.FindByField("organization_id", pattern))
+	require.Empty(t, tickets.FindByField("assignee_id", pattern))
+}
@@ -0,0 +1,521 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+//QuerySyntaxError points to the place in the query where something went wrong
+type QuerySyntaxError struct {
+	Query    string
+	Position int //1 based, counted in characters
+	Message  string
+}
+
+func (err *QuerySyntaxError) Error() string {
+	pointer := strings.Repeat(" ", err.Position-1) + "^"
+	return fmt.Sprintf("Syntax error at position %d: %s\n  %s\n  %s", err.Position, err.Message, err.Query, pointer)
+}
+
+//QueryNode is a node of a parsed query
+type QueryNode interface {
+	String() string
+	//Terms lists every field:value term in the query so fields can be validated
+	Terms() []*TermNode
+}
+
+//TermNode matches records where Field matches Value. ParseQuery leaves Value as the text typed in,
+//TypeQuery parses it for the type of the field so numeric fields get ranges and string fields keep text like "<3"
+type TermNode struct {
+	Field    string
+	Value    interface{}
+	Raw      string //the value as it was typed
+	Position int
+}
+
+type AndNode struct {
+	Left  QueryNode
+	Right QueryNode
+}
+
+type OrNode struct {
+	Left  QueryNode
+	Right QueryNode
+}
+
+type NotNode struct {
+	Operand QueryNode
+}
+
+func (node *TermNode) String() string {
+	return fmt.Sprintf("%s:%q", node.Field, node.Raw)
+}
+
+func (node *AndNode) String() string {
+	return fmt.Sprintf("(%s AND %s)", node.Left, node.Right)
+}
+
+func (node *OrNode) String() string {
+	return fmt.Sprintf("(%s OR %s)", node.Left, node.Right)
+}
+
+func (node *NotNode) String() string {
+	return fmt.Sprintf("NOT %s", node.Operand)
+}
+
+func (node *TermNode) Terms() []*TermNode {
+	return []*TermNode{node}
+}
+
+func (node *AndNode) Terms() []*TermNode {
+	return append(node.Left.Terms(), node.Right.Terms()...)
+}
+
+func (node *OrNode) Terms() []*TermNode {
+	return append(node.Left.Terms(), node.Right.Terms()...)
+}
+
+func (node *NotNode) Terms() []*TermNode {
+	return node.Operand.Terms()
+}
+
+type queryTokenType int
+
+const (
+	tokenEnd queryTokenType = iota
+	tokenOpenParen
+	tokenCloseParen
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenTerm
+	tokenWord //a word that is missing its field, only kept so the parser can report it
+)
+
+type queryToken struct {
+	tokenType queryTokenType
+	position  int
+	field     string
+	value     string
+}
+
+func (token queryToken) describe() string {
+	switch token.tokenType {
+	case tokenEnd:
+		return "the end of the query"
+	case tokenOpenParen:
+		return `"("`
+	case tokenCloseParen:
+		return `")"`
+	case tokenAnd:
+		return "AND"
+	case tokenOr:
+		return "OR"
+	case tokenNot:
+		return "NOT"
+	case tokenWord:
+		return fmt.Sprintf(`"%s"`, token.value)
+	default:
+		return fmt.Sprintf(`"%s:%s"`, token.field, token.value)
+	}
+}
+
+func isQueryFieldChar(char rune) bool {
+	return unicode.IsLetter(char) || unicode.IsDigit(char) || char == '_' || char == '.'
+}
+
+//lexQuery splits the query into tokens. Terms are field:value, where the value can be "quoted" to include spaces or brackets
+func lexQuery(query string) ([]queryToken, error) {
+	chars := []rune(query)
+	var tokens []queryToken
+
+	syntaxError := func(position int, format string, args ...interface{}) error {
+		return &QuerySyntaxError{Query: query, Position: position + 1, Message: fmt.Sprintf(format, args...)}
+	}
+
+	for i := 0; i < len(chars); {
+		char := chars[i]
+
+		switch {
+		case unicode.IsSpace(char):
+			i++
+		case char == '(':
+			tokens = append(tokens, queryToken{tokenType: tokenOpenParen, position: i + 1})
+			i++
+		case char == ')':
+			tokens = append(tokens, queryToken{tokenType: tokenCloseParen, position: i + 1})
+			i++
+		case isQueryFieldChar(char):
+			start := i
+			for i < len(chars) && isQueryFieldChar(chars[i]) {
+				i++
+			}
+			word := string(chars[start:i])
+
+			if i >= len(chars) || chars[i] != ':' {
+				switch strings.ToUpper(word) {
+				case "AND":
+					tokens = append(tokens, queryToken{tokenType: tokenAnd, position: start + 1})
+				case "OR":
+					tokens = append(tokens, queryToken{tokenType: tokenOr, position: start + 1})
+				case "NOT":
+					tokens = append(tokens, queryToken{tokenType: tokenNot, position: start + 1})
+				default:
+					tokens = append(tokens, queryToken{tokenType: tokenWord, position: start + 1, value: word})
+				}
+				continue
+			}
+
+			//skip the colon
+			i++
+
+			var value strings.Builder
+			if i < len(chars) && chars[i] == '"' {
+				quoteStart := i
+				i++
+				closed := false
+
+				for i < len(chars) {
+					if chars[i] == '\\' && i+1 < len(chars) {
+						value.WriteRune(chars[i+1])
+						i += 2
+						continue
+					}
+
+					if chars[i] == '"' {
+						closed = true
+						i++
+						break
+					}
+
+					value.WriteRune(chars[i])
+					i++
+				}
+
+				if !closed {
+					return nil, syntaxError(quoteStart, "unterminated quote")
+				}
+			} else {
+				for i < len(chars) && !unicode.IsSpace(chars[i]) && chars[i] != '(' && chars[i] != ')' {
+					value.WriteRune(chars[i])
+					i++
+				}
+			}
+
+			tokens = append(tokens, queryToken{tokenType: tokenTerm, position: start + 1, field: word, value: value.String()})
+		default:
+			return nil, syntaxError(i, `unexpected character "%c"`, char)
+		}
+	}
+
+	tokens = append(tokens, queryToken{tokenType: tokenEnd, position: len(chars) + 1})
+
+	return tokens, nil
+}
+
+//queryParser is a recursive descent parser for:
+//
+//	or   = and { "OR" and }
+//	and  = not { ["AND"] not }
+//	not  = "NOT" not | atom
+//	atom = "(" or ")" | field ":" value
+type queryParser struct {
+	query  string
+	tokens []queryToken
+	next   int
+}
+
+func (parser *queryParser) peek() queryToken {
+	return parser.tokens[parser.next]
+}
+
+func (parser *queryParser) advance() queryToken {
+	token := parser.tokens[parser.next]
+	if token.tokenType != tokenEnd {
+		parser.next++
+	}
+
+	return token
+}
+
+func (parser *queryParser) syntaxError(token queryToken, format string, args ...interface{}) error {
+	return &QuerySyntaxError{Query: parser.query, Position: token.position, Message: fmt.Sprintf(format, args...)}
+}
+
+func (parser *queryParser) parseOr() (QueryNode, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.peek().tokenType == tokenOr {
+		parser.advance()
+
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &OrNode{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (parser *queryParser) parseAnd() (QueryNode, error) {
+	left, err := parser.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch parser.peek().tokenType {
+		case tokenAnd:
+			parser.advance()
+		case tokenNot, tokenOpenParen, tokenTerm, tokenWord:
+			//terms next to each other are ANDed together
+		default:
+			return left, nil
+		}
+
+		right, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &AndNode{Left: left, Right: right}
+	}
+}
+
+func (parser *queryParser) parseNot() (QueryNode, error) {
+	if parser.peek().tokenType == tokenNot {
+		parser.advance()
+
+		operand, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return &NotNode{Operand: operand}, nil
+	}
+
+	return parser.parseAtom()
+}
+
+func (parser *queryParser) parseAtom() (QueryNode, error) {
+	token := parser.advance()
+
+	switch token.tokenType {
+	case tokenOpenParen:
+		node, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := parser.advance(); closing.tokenType != tokenCloseParen {
+			return nil, parser.syntaxError(closing, `expected ")" to close the "(" at position %d but found %s`, token.position, closing.describe())
+		}
+
+		return node, nil
+
+	case tokenTerm:
+		return &TermNode{Field: token.field, Value: token.value, Raw: token.value, Position: token.position}, nil
+
+	default:
+		return nil, parser.syntaxError(token, "expected field:value, NOT or \"(\" but found %s", token.describe())
+	}
+}
+
+//ParseQuery parses a query like `status:pending AND (type:incident OR type:problem) AND NOT tags:Ohio`.
+//AND binds tighter than OR, terms next to each other are ANDed, and keywords are not case sensitive.
+//Errors are *QuerySyntaxError values that point to where the problem is
+func ParseQuery(query string) (QueryNode, error) {
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &queryParser{query: query, tokens: tokens}
+
+	if parser.peek().tokenType == tokenEnd {
+		return nil, parser.syntaxError(parser.peek(), "the query is empty")
+	}
+
+	node, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if leftover := parser.peek(); leftover.tokenType != tokenEnd {
+		return nil, parser.syntaxError(leftover, "unexpected %s", leftover.describe())
+	}
+
+	return node, nil
+}
+
+//ValidateQueryFields makes sure every term in the query uses one of the given fields
+func ValidateQueryFields(query string, node QueryNode, fields []string) error {
+	for _, term := range node.Terms() {
+		valid := false
+		for _, fieldName := range fields {
+			if term.Field == fieldName {
+				valid = true
+				break
+			}
+		}
+
+		if !valid {
+			return &QuerySyntaxError{Query: query, Position: term.Position, Message: fmt.Sprintf(`Invalid field "%s"`, term.Field)}
+		}
+	}
+
+	return nil
+}
+
+//TypeQuery makes sure every term in the query uses a field of the schema, with a value that fits the field's type.
+//It returns a copy of the query with each value parsed for its field, so the same query can be typed for several schemas
+func TypeQuery(query string, node QueryNode, schema EntitySchema) (QueryNode, error) {
+	if err := ValidateQueryFields(query, node, schema.FieldNames()); err != nil {
+		return nil, err
+	}
+
+	return typeQueryNode(query, node, schema)
+}
+
+func typeQueryNode(query string, node QueryNode, schema EntitySchema) (QueryNode, error) {
+	switch typedNode := node.(type) {
+	case *TermNode:
+		field, _ := schema.Field(typedNode.Field)
+
+		value, err := field.ParseTerm(typedNode.Raw)
+		if err != nil {
+			return nil, &QuerySyntaxError{Query: query, Position: typedNode.Position, Message: err.Error()}
+		}
+
+		term := *typedNode
+		term.Value = value
+		return &term, nil
+
+	case *AndNode:
+		left, right, err := typeQueryNodes(query, typedNode.Left, typedNode.Right, schema)
+		if err != nil {
+			return nil, err
+		}
+
+		return &AndNode{Left: left, Right: right}, nil
+
+	case *OrNode:
+		left, right, err := typeQueryNodes(query, typedNode.Left, typedNode.Right, schema)
+		if err != nil {
+			return nil, err
+		}
+
+		return &OrNode{Left: left, Right: right}, nil
+
+	case *NotNode:
+		operand, err := typeQueryNode(query, typedNode.Operand, schema)
+		if err != nil {
+			return nil, err
+		}
+
+		return &NotNode{Operand: operand}, nil
+
+	default:
+		return node, nil
+	}
+}
+
+func typeQueryNodes(query string, left QueryNode, right QueryNode, schema EntitySchema) (QueryNode, QueryNode, error) {
+	typedLeft, err := typeQueryNode(query, left, schema)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	typedRight, err := typeQueryNode(query, right, schema)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return typedLeft, typedRight, nil
+}
+
+//RecordFinder is the part of the repositories that queries are evaluated against
+type RecordFinder interface {
+	FindByField(fieldName string, searchVal interface{}) []map[string]interface{}
+	FindAll() []map[string]interface{}
+}
+
+//recordSet is keyed by "_id" since that is unique within one type of record
+type recordSet map[string]map[string]interface{}
+
+func recordKey(record map[string]interface{}) string {
+	return stringVal(record["_id"])
+}
+
+func newRecordSet(records []map[string]interface{}) recordSet {
+	set := make(recordSet, len(records))
+	for _, record := range records {
+		set[recordKey(record)] = record
+	}
+
+	return set
+}
+
+func evaluateQuery(node QueryNode, finder RecordFinder) recordSet {
+	switch typedNode := node.(type) {
+	case *TermNode:
+		return newRecordSet(finder.FindByField(typedNode.Field, typedNode.Value))
+
+	case *AndNode:
+		left := evaluateQuery(typedNode.Left, finder)
+		if len(left) == 0 {
+			return left
+		}
+
+		right := evaluateQuery(typedNode.Right, finder)
+		for key := range left {
+			if _, inBoth := right[key]; !inBoth {
+				delete(left, key)
+			}
+		}
+
+		return left
+
+	case *OrNode:
+		left := evaluateQuery(typedNode.Left, finder)
+		for key, record := range evaluateQuery(typedNode.Right, finder) {
+			left[key] = record
+		}
+
+		return left
+
+	case *NotNode:
+		operand := evaluateQuery(typedNode.Operand, finder)
+		everything := newRecordSet(finder.FindAll())
+
+		for key := range operand {
+			delete(everything, key)
+		}
+
+		return everything
+
+	default:
+		return recordSet{}
+	}
+}
+
+//EvaluateQuery finds the records matching the query. Each term is looked up with FindByField, so indexes and value matchers are used as normal
+func EvaluateQuery(node QueryNode, finder RecordFinder) []map[string]interface{} {
+	matches := evaluateQuery(node, finder)
+	results := []map[string]interface{}{}
+
+	//go through FindAll so the results come out in the same order as everything else
+	for _, record := range finder.FindAll() {
+		if _, matched := matches[recordKey(record)]; matched {
+			results = append(results, record)
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,233 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+func Test_ParseQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "single term",
+			query:    "status:pending",
+			expected: `status:"pending"`,
+		},
+		{
+			name:     "AND binds tighter than OR",
+			query:    "type:incident OR type:problem AND status:open",
+			expected: `(type:"incident" OR (type:"problem" AND status:"open"))`,
+		},
+		{
+			name:     "brackets",
+			query:    "(type:incident OR type:problem) AND has_incidents:true",
+			expected: `((type:"incident" OR type:"problem") AND has_incidents:"true")`,
+		},
+		{
+			name:     "NOT",
+			query:    "status:pending AND priority:high AND NOT tags:Ohio",
+			expected: `((status:"pending" AND priority:"high") AND NOT tags:"Ohio")`,
+		},
+		{
+			name:     "terms next to each other are ANDed",
+			query:    "status:pending priority:high",
+			expected: `(status:"pending" AND priority:"high")`,
+		},
+		{
+			name:     "lower case keywords",
+			query:    "status:pending or not priority:high",
+			expected: `(status:"pending" OR NOT priority:"high")`,
+		},
+		{
+			name:     "quoted values",
+			query:    `subject:"A Catastrophe in Korea (North)"`,
+			expected: `subject:"A Catastrophe in Korea (North)"`,
+		},
+		{
+			name:     "empty value",
+			query:    "alias:",
+			expected: `alias:""`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := search.ParseQuery(tt.query)
+			require.Nil(t, err)
+			require.Equal(t, tt.expected, node.String())
+		})
+	}
+}
+
+func Test_ParseQuery_Ranges(t *testing.T) {
+	query := "_id:10..25"
+	node, err := search.ParseQuery(query)
+	require.Nil(t, err)
+
+	//the parser doesn't know the field types, so the value stays as it was typed until TypeQuery
+	require.Equal(t, "10..25", node.(*search.TermNode).Value)
+
+	typed, err := search.TypeQuery(query, node, search.DefaultSchema.Entity(search.UsersGroup))
+	require.Nil(t, err)
+
+	term := typed.(*search.TermNode)
+	require.IsType(t, search.NumericRange{}, term.Value)
+	require.Equal(t, "10..25", term.Raw)
+}
+
+func Test_ParseQuery_Errors(t *testing.T) {
+	tests := []struct {
+		name             string
+		query            string
+		expectedPosition int
+	}{
+		{
+			name:             "empty",
+			query:            "",
+			expectedPosition: 1,
+		},
+		{
+			name:             "word without a field",
+			query:            "status:pending AND pending",
+			expectedPosition: 20,
+		},
+		{
+			name:             "two operators",
+			query:            "status:pending AND OR priority:high",
+			expectedPosition: 20,
+		},
+		{
+			name:             "missing closing bracket",
+			query:            "(type:incident OR type:problem",
+			expectedPosition: 31,
+		},
+		{
+			name:             "extra closing bracket",
+			query:            "type:incident)",
+			expectedPosition: 14,
+		},
+		{
+			name:             "unterminated quote",
+			query:            `subject:"A Catastrophe`,
+			expectedPosition: 9,
+		},
+		{
+			name:             "unexpected character",
+			query:            "status:open & priority:high",
+			expectedPosition: 13,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := search.ParseQuery(tt.query)
+			require.NotNil(t, err)
+
+			syntaxErr, isSyntaxErr := err.(*search.QuerySyntaxError)
+			require.True(t, isSyntaxErr)
+			require.Equal(t, tt.expectedPosition, syntaxErr.Position)
+		})
+	}
+}
+
+func Test_ValidateQueryFields(t *testing.T) {
+	query := "status:pending AND NOT colour:red"
+	node, err := search.ParseQuery(query)
+	require.Nil(t, err)
+
+	require.Nil(t, search.ValidateQueryFields(query, node, []string{"status", "colour"}))
+
+	err = search.ValidateQueryFields(query, node, []string{"status"})
+	require.NotNil(t, err)
+	require.Equal(t, 24, err.(*search.QuerySyntaxError).Position)
+}
+
+func Test_TypeQuery(t *testing.T) {
+	users := search.DefaultSchema.Entity(search.UsersGroup)
+
+	query := "active:true AND NOT _id:10..25"
+	node, err := search.ParseQuery(query)
+	require.Nil(t, err)
+
+	typed, err := search.TypeQuery(query, node, users)
+	require.Nil(t, err)
+
+	terms := typed.Terms()
+	require.Equal(t, true, terms[0].Value)
+	require.IsType(t, search.NumericRange{}, terms[1].Value)
+
+	//the parsed query is left alone so it can be typed for other schemas
+	require.Equal(t, "true", node.Terms()[0].Value)
+
+	query = "role:admin AND active:maybe"
+	node, err = search.ParseQuery(query)
+	require.Nil(t, err)
+
+	_, err = search.TypeQuery(query, node, users)
+	require.NotNil(t, err)
+	require.Equal(t, 16, err.(*search.QuerySyntaxError).Position)
+
+	query = "role:admin _id:1..x"
+	node, err = search.ParseQuery(query)
+	require.Nil(t, err)
+
+	_, err = search.TypeQuery(query, node, users)
+	require.NotNil(t, err)
+	require.Equal(t, 12, err.(*search.QuerySyntaxError).Position)
+}
+
+func Test_TypeQuery_TextThatLooksLikeNumbers(t *testing.T) {
+	ticketList := []map[string]interface{}{
+		{"_id": "1", "subject": "wait...", "external_id": "10"},
+		{"_id": "2", "subject": "<3", "external_id": "25"},
+		{"_id": "3", "subject": "A Problem in Russia", "external_id": "10..25"},
+	}
+	tickets, err := search.NewTicketJSONRepository(ticketList)
+	require.Nil(t, err)
+
+	query := func(query string) []map[string]interface{} {
+		node, err := search.ParseQuery(query)
+		require.Nil(t, err)
+
+		typed, err := search.TypeQuery(query, node, search.DefaultSchema.Entity(search.TicketsGroup))
+		require.Nil(t, err)
+
+		return search.EvaluateQuery(typed, tickets)
+	}
+
+	//range syntax is only for numeric fields, so text fields match it as text
+	require.Equal(t, []map[string]interface{}{ticketList[0]}, query(`subject:"wait..."`))
+	require.Equal(t, []map[string]interface{}{ticketList[1]}, query(`subject:"<3"`))
+	require.Equal(t, []map[string]interface{}{ticketList[2]}, query("external_id:10..25"))
+	require.Equal(t, []map[string]interface{}{ticketList[0]}, query("external_id:10"))
+}
+
+func Test_EvaluateQuery(t *testing.T) {
+	ticketList := []map[string]interface{}{
+		{"_id": "1", "status": "pending", "priority": "high", "type": "incident", "tags": []interface{}{"Ohio"}, "has_incidents": true},
+		{"_id": "2", "status": "pending", "priority": "high", "type": "problem", "tags": []interface{}{"Utah"}, "has_incidents": true},
+		{"_id": "3", "status": "open", "priority": "high", "type": "question", "tags": []interface{}{"Ohio"}, "has_incidents": false},
+		{"_id": "4", "status": "pending", "priority": "low", "type": "incident", "tags": []interface{}{}, "has_incidents": false},
+	}
+	tickets, err := search.NewTicketJSONRepository(ticketList)
+	require.Nil(t, err)
+
+	query := func(query string) []map[string]interface{} {
+		node, err := search.ParseQuery(query)
+		require.Nil(t, err)
+		return search.EvaluateQuery(node, tickets)
+	}
+
+	require.ElementsMatch(t, []map[string]interface{}{ticketList[1]}, query("status:pending AND priority:high AND NOT tags:Ohio"))
+	require.ElementsMatch(t, []map[string]interface{}{ticketList[0], ticketList[1]}, query("(type:incident OR type:problem) AND has_incidents:true"))
+	require.ElementsMatch(t, []map[string]interface{}{ticketList[2], ticketList[3]}, query("NOT (status:pending AND priority:high)"))
+	require.ElementsMatch(t, []map[string]interface{}{ticketList[3]}, query("tags:"))
+	require.Empty(t, query("status:solved"))
+}
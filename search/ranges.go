@@ -0,0 +1,154 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//NumericRange is a search term that matches numbers between Min and Max.
+//Open ended ranges use infinity for the missing side
+type NumericRange struct {
+	Min          float64
+	Max          float64
+	MinInclusive bool
+	MaxInclusive bool
+}
+
+//Contains checks if the value is inside the range
+func (numRange NumericRange) Contains(value float64) bool {
+	if value < numRange.Min || (value == numRange.Min && !numRange.MinInclusive) {
+		return false
+	}
+
+	if value > numRange.Max || (value == numRange.Max && !numRange.MaxInclusive) {
+		return false
+	}
+
+	return true
+}
+
+//IsRangeTerm checks if a search term uses the range syntax understood by ParseNumericRange
+func IsRangeTerm(term string) bool {
+	return strings.HasPrefix(term, ">") || strings.HasPrefix(term, "<") || strings.Contains(term, "..")
+}
+
+func parseRangeNumber(term string, number string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil {
+		return 0, fmt.Errorf(`Invalid range "%v": "%v" is not a number`, term, number)
+	}
+
+	return value, nil
+}
+
+//ParseNumericRange understands "10..25" (both ends included), "10.." and "..25" for open ranges, and ">50", ">=50", "<3" and "<=3"
+func ParseNumericRange(term string) (NumericRange, error) {
+	numRange := NumericRange{
+		Min:          math.Inf(-1),
+		Max:          math.Inf(1),
+		MinInclusive: true,
+		MaxInclusive: true,
+	}
+
+	var err error
+
+	switch {
+	case strings.HasPrefix(term, ">="):
+		numRange.Min, err = parseRangeNumber(term, term[2:])
+	case strings.HasPrefix(term, ">"):
+		numRange.Min, err = parseRangeNumber(term, term[1:])
+		numRange.MinInclusive = false
+	case strings.HasPrefix(term, "<="):
+		numRange.Max, err = parseRangeNumber(term, term[2:])
+	case strings.HasPrefix(term, "<"):
+		numRange.Max, err = parseRangeNumber(term, term[1:])
+		numRange.MaxInclusive = false
+	case strings.Contains(term, ".."):
+		bounds := strings.SplitN(term, "..", 2)
+		if bounds[0] == "" && bounds[1] == "" {
+			return numRange, fmt.Errorf(`Invalid range "%v": at least one end of the range is needed`, term)
+		}
+
+		if bounds[0] != "" {
+			if numRange.Min, err = parseRangeNumber(term, bounds[0]); err != nil {
+				return numRange, err
+			}
+		}
+
+		if bounds[1] != "" {
+			if numRange.Max, err = parseRangeNumber(term, bounds[1]); err != nil {
+				return numRange, err
+			}
+		}
+
+		if numRange.Min > numRange.Max {
+			return numRange, fmt.Errorf(`Invalid range "%v": the start is bigger than the end`, term)
+		}
+	default:
+		return numRange, fmt.Errorf(`Invalid range "%v"`, term)
+	}
+
+	return numRange, err
+}
+
+type numericEntry struct {
+	value  float64
+	record map[string]interface{}
+}
+
+//numericIndex keeps a sorted list of the values of every numeric field, so ranges can be found with a binary search instead of a scan
+type numericIndex struct {
+	fieldNames []string
+	fields     map[string][]numericEntry
+}
+
+func newNumericIndex(fieldNames ...string) *numericIndex {
+	return &numericIndex{
+		fieldNames: fieldNames,
+		fields:     make(map[string][]numericEntry),
+	}
+}
+
+//add indexes the record's numeric fields. build must be called once all the records are added
+func (index *numericIndex) add(record map[string]interface{}) {
+	for _, fieldName := range index.fieldNames {
+		if value, isFloat := record[fieldName].(float64); isFloat {
+			index.fields[fieldName] = append(index.fields[fieldName], numericEntry{value: value, record: record})
+		}
+	}
+}
+
+func (index *numericIndex) build() {
+	for _, entries := range index.fields {
+		entries := entries
+		//stable so that records with the same value stay in the order they were added
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].value < entries[j].value
+		})
+	}
+}
+
+//find returns the records with a value in range, lowest value first
+func (index *numericIndex) find(fieldName string, numRange NumericRange) []map[string]interface{} {
+	entries := index.fields[fieldName]
+	results := []map[string]interface{}{}
+
+	start := sort.Search(len(entries), func(i int) bool {
+		return entries[i].value >= numRange.Min
+	})
+
+	for i := start; i < len(entries); i++ {
+		if entries[i].value > numRange.Max {
+			break
+		}
+
+		if numRange.Contains(entries[i].value) {
+			results = append(results, entries[i].record)
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,120 @@
+package search_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+func Test_ParseNumericRange(t *testing.T) {
+	tests := []struct {
+		name          string
+		term          string
+		expectedRange search.NumericRange
+		expectError   bool
+	}{
+		{
+			name:          "closed range",
+			term:          "10..25",
+			expectedRange: search.NumericRange{Min: 10, Max: 25, MinInclusive: true, MaxInclusive: true},
+		},
+		{
+			name:          "open end",
+			term:          "10..",
+			expectedRange: search.NumericRange{Min: 10, Max: math.Inf(1), MinInclusive: true, MaxInclusive: true},
+		},
+		{
+			name:          "open start",
+			term:          "..25",
+			expectedRange: search.NumericRange{Min: math.Inf(-1), Max: 25, MinInclusive: true, MaxInclusive: true},
+		},
+		{
+			name:          "greater or equal",
+			term:          ">=50",
+			expectedRange: search.NumericRange{Min: 50, Max: math.Inf(1), MinInclusive: true, MaxInclusive: true},
+		},
+		{
+			name:          "greater than",
+			term:          ">50",
+			expectedRange: search.NumericRange{Min: 50, Max: math.Inf(1), MinInclusive: false, MaxInclusive: true},
+		},
+		{
+			name:          "less than",
+			term:          "<3",
+			expectedRange: search.NumericRange{Min: math.Inf(-1), Max: 3, MinInclusive: true, MaxInclusive: false},
+		},
+		{
+			name:          "less or equal with decimals",
+			term:          "<=3.5",
+			expectedRange: search.NumericRange{Min: math.Inf(-1), Max: 3.5, MinInclusive: true, MaxInclusive: true},
+		},
+		{
+			name:        "not a number",
+			term:        ">=fifty",
+			expectError: true,
+		},
+		{
+			name:        "no ends",
+			term:        "..",
+			expectError: true,
+		},
+		{
+			name:        "backwards range",
+			term:        "25..10",
+			expectError: true,
+		},
+		{
+			name:        "too many dots",
+			term:        "1..2..3",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			numRange, err := search.ParseNumericRange(tt.term)
+			if tt.expectError {
+				require.NotNil(t, err)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, tt.expectedRange, numRange)
+		})
+	}
+}
+
+func Test_NumericRange_FindByField(t *testing.T) {
+	userList := []map[string]interface{}{
+		{"_id": float64(30), "organization_id": float64(101)},
+		{"_id": float64(10), "organization_id": float64(119)},
+		{"_id": float64(25)},
+		{"_id": float64(2), "organization_id": float64(101)},
+	}
+	users, err := search.NewUserJSONRepository(userList)
+	require.Nil(t, err)
+
+	idRange, _ := search.ParseNumericRange("10..25")
+	require.Equal(t, []map[string]interface{}{userList[1], userList[2]}, users.FindByField("_id", idRange))
+
+	lessThan, _ := search.ParseNumericRange("<10")
+	require.Equal(t, []map[string]interface{}{userList[3]}, users.FindByField("_id", lessThan))
+
+	orgRange, _ := search.ParseNumericRange(">=110")
+	require.Equal(t, []map[string]interface{}{userList[1]}, users.FindByField("organization_id", orgRange))
+
+	//string fields are never in a numeric range
+	require.Empty(t, users.FindByField("name", orgRange))
+
+	ticketList := []map[string]interface{}{
+		{"_id": "abcd", "submitter_id": float64(38)},
+		{"_id": "efgh", "submitter_id": float64(50)},
+	}
+	tickets, err := search.NewTicketJSONRepository(ticketList)
+	require.Nil(t, err)
+
+	require.Equal(t, []map[string]interface{}{ticketList[1]}, tickets.FindByField("submitter_id", search.NumericRange{Min: 50, Max: 50, MinInclusive: true, MaxInclusive: true}))
+}
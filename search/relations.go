@@ -0,0 +1,469 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//Relation links the records of one entity type to related records of another. Related records are added to results under Name
+type Relation struct {
+	Name     string
+	From     string //the entity type the relation is added to, like UsersGroup
+	To       string //the entity type of the related records
+	Many     bool   //a list of related records rather than a single one
+	Field    string //the reference field that links the records
+	Backward bool   //Field belongs to the related records and holds the "_id" of the record the relation is added to
+
+	keyType FieldType //the type of the "_id" Field holds
+}
+
+//relationName is the name the records a reference field points at are added under when the schema doesn't give one
+func relationName(field FieldSchema) string {
+	if field.Relation != "" {
+		return field.Relation
+	}
+
+	if field.Type == FieldReferenceList && strings.HasSuffix(field.Name, "_ids") {
+		return strings.TrimSuffix(field.Name, "_ids") + "s"
+	}
+
+	return strings.TrimSuffix(field.Name, "_id")
+}
+
+//Relations lists the relations that can be added to records of the entity type, in the order they are added to results.
+//Every reference field of the type adds the records it points at, and every reference field of any type
+//with an inverse name that points at this type adds the records that hold the reference
+func (schema Schema) Relations(entity string) []Relation {
+	var relations []Relation
+
+	for _, field := range schema.Entity(entity).Fields {
+		if field.IsReference() {
+			relations = append(relations, Relation{
+				Name: relationName(field), From: entity, To: field.Target, Many: field.Type == FieldReferenceList,
+				Field: field.Name, keyType: field.keyType,
+			})
+		}
+	}
+
+	for _, name := range schema.Names() {
+		for _, field := range schema.Entity(name).Fields {
+			if field.IsReference() && field.Target == entity && field.Inverse != "" {
+				relations = append(relations, Relation{
+					Name: field.Inverse, From: entity, To: name, Many: !field.InverseOne,
+					Field: field.Name, Backward: true, keyType: field.keyType,
+				})
+			}
+		}
+	}
+
+	return relations
+}
+
+//find looks up the records related to record
+func (relation Relation) find(repo *SearchRepository, record map[string]interface{}) []map[string]interface{} {
+	if relation.Backward {
+		return repo.findReferencing(relation.To, relation.Field, record["_id"])
+	}
+
+	ids := []interface{}{record[relation.Field]}
+	if relation.Many {
+		ids = sliceVal(record[relation.Field])
+	}
+
+	var related []map[string]interface{}
+	for _, id := range ids {
+		if id == nil {
+			continue
+		}
+
+		if key, isKey := idKey(relation.keyType, id); isKey {
+			if found := repo.findByID(relation.To, key); found != nil {
+				related = append(related, found)
+			}
+		}
+	}
+
+	return related
+}
+
+//referenceFinder is implemented by repositories that can look up records by the ID in one of their reference fields
+type referenceFinder interface {
+	FindByReference(fieldName string, id interface{}) []map[string]interface{}
+}
+
+//findReferencing finds the records of a type that have the ID in one of their reference fields
+func (repo *SearchRepository) findReferencing(entity string, fieldName string, id interface{}) []map[string]interface{} {
+	records, canFind := repo.finder(entity).(referenceFinder)
+	if !canFind || id == nil {
+		return nil
+	}
+
+	return records.FindByReference(fieldName, id)
+}
+
+//findByID looks up a record through whichever FindByID the repository has. key is a float64 or a string, as idKey makes them,
+//since UserRepository and the like take their IDs typed
+func (repo *SearchRepository) findByID(entity string, key interface{}) map[string]interface{} {
+	switch records := repo.finder(entity).(type) {
+	case interface {
+		FindByID(id interface{}) map[string]interface{}
+	}:
+		return records.FindByID(key)
+	case interface {
+		FindByID(id float64) map[string]interface{}
+	}:
+		if id, isFloat := key.(float64); isFloat {
+			return records.FindByID(id)
+		}
+	case interface {
+		FindByID(id string) map[string]interface{}
+	}:
+		if id, isString := key.(string); isString {
+			return records.FindByID(id)
+		}
+	}
+
+	return nil
+}
+
+//ExpandOptions decide which relations are added to results.
+//Depth is how many links to follow, so a depth of 2 adds the organization of a ticket's assigned user. 0 turns relations off.
+//Relations limits the relations added at every level by name, all of them are added if it's empty
+//Mode is one of RelationModes, and SummaryFields replaces the DefaultSummaryFields of an entity type in summary mode.
+//Fields cuts the results down to the listed fields, see ProjectFields. Relations that no field needs are not looked up at all
+type ExpandOptions struct {
+	Relations     []string
+	Depth         int
+	Mode          string
+	SummaryFields map[string][]string
+	Fields        []string
+}
+
+//relation modes decide how related records are shown
+const (
+	RelationsFull    = "full"    //the whole record
+	RelationsSummary = "summary" //only the summary fields of the record
+	RelationsIDs     = "ids"     //only the "_id" of the record
+)
+
+var RelationModes = []string{RelationsFull, RelationsSummary, RelationsIDs}
+
+//DefaultSummaryFields are the fields kept for each entity type in summary mode
+var DefaultSummaryFields = map[string][]string{
+	UsersGroup:               {"_id", "name"},
+	OrganizationsGroup:       {"_id", "name"},
+	TicketsGroup:             {"_id", "subject", "status"},
+	GroupsGroup:              {"_id", "name"},
+	TicketCommentsGroup:      {"_id", "body"},
+	SatisfactionRatingsGroup: {"_id", "score"},
+}
+
+//DefaultExpandOptions adds every full relation, one level deep
+var DefaultExpandOptions = ExpandOptions{Depth: 1, Mode: RelationsFull}
+
+func (options ExpandOptions) summaryFields(entity string) []string {
+	if fields, hasFields := options.SummaryFields[entity]; hasFields {
+		return fields
+	}
+
+	return DefaultSummaryFields[entity]
+}
+
+func (options ExpandOptions) includes(relation Relation) bool {
+	if len(options.Relations) == 0 {
+		return true
+	}
+
+	for _, name := range options.Relations {
+		if name == relation.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+//includesAt checks if the relation is added at path, the dotted path of relations that led to it like "assigned_user."
+func (options ExpandOptions) includesAt(relation Relation, path string) bool {
+	if !options.includes(relation) {
+		return false
+	}
+
+	if len(options.Fields) == 0 {
+		return true
+	}
+
+	relationPath := path + relation.Name
+	for _, field := range options.Fields {
+		if field == relationPath || strings.HasPrefix(field, relationPath+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+//FieldsDepth is the depth needed to reach every field in a list like ["name", "assigned_user.organization.name"], 2 in that case
+func FieldsDepth(schema Schema, entity string, fields []string) int {
+	depth := 0
+
+	for _, field := range fields {
+		fieldDepth := 0
+		from := entity
+
+		for _, name := range strings.Split(field, ".") {
+			var next *Relation
+			for _, relation := range schema.Relations(from) {
+				if relation.Name == name {
+					relation := relation
+					next = &relation
+					break
+				}
+			}
+
+			if next == nil {
+				break
+			}
+
+			fieldDepth++
+			from = next.To
+		}
+
+		if fieldDepth > depth {
+			depth = fieldDepth
+		}
+	}
+
+	return depth
+}
+
+//resultFields are added to records by the search itself, so they are kept when the results are cut down to a list of fields
+var resultFields = []string{"_score", "_explain", "_matched_fields"}
+
+//ProjectFields returns a copy of the record with only the listed fields. Dotted paths like "organization.name" keep
+//fields of related records, and for lists of related records like "assigned_tickets.subject" every record in the list is cut down
+func ProjectFields(record map[string]interface{}, fields []string) map[string]interface{} {
+	projected := map[string]interface{}{}
+	subFields := map[string][]string{}
+
+	for _, field := range fields {
+		parts := strings.SplitN(field, ".", 2)
+		value, hasField := record[parts[0]]
+		if !hasField {
+			continue
+		}
+
+		if len(parts) == 1 {
+			projected[parts[0]] = value
+			subFields[parts[0]] = nil
+			continue
+		}
+
+		if _, wholeValue := projected[parts[0]]; wholeValue && subFields[parts[0]] == nil {
+			continue
+		}
+
+		subFields[parts[0]] = append(subFields[parts[0]], parts[1])
+	}
+
+	for fieldName, paths := range subFields {
+		if paths == nil {
+			continue
+		}
+
+		switch value := record[fieldName].(type) {
+		case map[string]interface{}:
+			projected[fieldName] = ProjectFields(value, paths)
+		case []map[string]interface{}:
+			list := make([]map[string]interface{}, len(value))
+			for i, item := range value {
+				list[i] = ProjectFields(item, paths)
+			}
+
+			projected[fieldName] = list
+		}
+	}
+
+	for _, fieldName := range resultFields {
+		if value, hasField := record[fieldName]; hasField {
+			projected[fieldName] = value
+		}
+	}
+
+	return projected
+}
+
+//reachableRelations finds the names of the relations that can be followed from the entity type within the depth.
+//Only relations the options include are followed
+func reachableRelations(schema Schema, entity string, options ExpandOptions) map[string]bool {
+	reachable := map[string]bool{}
+	entities := []string{entity}
+
+	for level := 0; level < options.Depth && len(entities) > 0; level++ {
+		var nextEntities []string
+
+		for _, from := range entities {
+			for _, relation := range schema.Relations(from) {
+				if options.includes(relation) {
+					reachable[relation.Name] = true
+					nextEntities = append(nextEntities, relation.To)
+				}
+			}
+		}
+
+		entities = uniqueStrings(nextEntities)
+	}
+
+	return reachable
+}
+
+//ValidateExpandOptions makes sure the mode is valid and every relation name can be reached from the entity type within the depth
+func ValidateExpandOptions(schema Schema, entity string, options ExpandOptions) error {
+	if options.Depth < 0 {
+		return fmt.Errorf("Invalid depth %d, it can't be negative", options.Depth)
+	}
+
+	validMode := options.Mode == ""
+	for _, mode := range RelationModes {
+		if options.Mode == mode {
+			validMode = true
+		}
+	}
+
+	if !validMode {
+		return fmt.Errorf(`Invalid relation mode "%v", expected one of: %v`, options.Mode, strings.Join(RelationModes, ", "))
+	}
+
+	reachable := reachableRelations(schema, entity, options)
+
+	for _, name := range options.Relations {
+		if !reachable[name] {
+			allNames := sortedKeys(reachableRelations(schema, entity, ExpandOptions{Depth: options.Depth}))
+			return fmt.Errorf(`Invalid relation "%v" for %v with a depth of %d, expected one of: %v`, name, entity, options.Depth, strings.Join(allNames, ", "))
+		}
+	}
+
+	return nil
+}
+
+func uniqueStrings(values []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+
+	return unique
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+//SetExpandOptions changes which relations are added to the results of Find, Query and the like
+func (repo *SearchRepository) SetExpandOptions(options ExpandOptions) {
+	repo.expandOptions = options
+}
+
+//result builds what a search returns for a stored record: a copy with its relations added, cut down to the fields asked for
+func (repo *SearchRepository) result(entity string, stored map[string]interface{}) map[string]interface{} {
+	record := repo.addRelations(entity, copyRecord(stored), stored, repo.expandOptions.Depth, "")
+
+	if len(repo.expandOptions.Fields) > 0 {
+		return ProjectFields(record, repo.expandOptions.Fields)
+	}
+
+	return record
+}
+
+//expand returns copies of the records with their relations added. The records from the repositories
+//are never changed, otherwise the relations would end up in the stored data and be searched or embedded again next time
+func (repo *SearchRepository) expand(entity string, records []map[string]interface{}) []map[string]interface{} {
+	results := make([]map[string]interface{}, len(records))
+
+	for i, stored := range records {
+		results[i] = repo.result(entity, stored)
+	}
+
+	return results
+}
+
+//addRelations adds the related records of stored to record, which is the copy or summary of it that ends up in the results.
+//path is the dotted path of relations that led to the record, used to check the relation is needed by one of the fields
+func (repo *SearchRepository) addRelations(entity string, record map[string]interface{}, stored map[string]interface{}, depth int, path string) map[string]interface{} {
+	if depth <= 0 {
+		return record
+	}
+
+	for _, relation := range repo.relationsFrom(entity) {
+		//relations to types of record that weren't loaded are left out rather than shown as empty
+		if !repo.expandOptions.includesAt(relation, path) || !repo.Loaded(relation.To) {
+			continue
+		}
+
+		related := relation.find(repo, stored)
+		relationPath := path + relation.Name + "."
+
+		if relation.Many {
+			record[relation.Name] = repo.renderRelated(relation.To, related, depth-1, relationPath)
+		} else if len(related) > 0 {
+			switch rendered := repo.renderRelated(relation.To, related[:1], depth-1, relationPath).(type) {
+			case []interface{}:
+				record[relation.Name] = rendered[0]
+			case []map[string]interface{}:
+				record[relation.Name] = rendered[0]
+			}
+		}
+	}
+
+	return record
+}
+
+//renderRelated shows the related records the way the relation mode asks for. In ids mode only the "_id" values are used, so no deeper relations are added
+func (repo *SearchRepository) renderRelated(entity string, related []map[string]interface{}, depth int, path string) interface{} {
+	switch repo.expandOptions.Mode {
+	case RelationsIDs:
+		ids := make([]interface{}, len(related))
+		for i, record := range related {
+			ids[i] = record["_id"]
+		}
+
+		return ids
+
+	case RelationsSummary:
+		summaryFields := repo.expandOptions.summaryFields(entity)
+		summaries := make([]map[string]interface{}, len(related))
+
+		for i, stored := range related {
+			summary := make(map[string]interface{}, len(summaryFields))
+			for _, fieldName := range summaryFields {
+				if value, hasField := stored[fieldName]; hasField {
+					summary[fieldName] = copyValue(value)
+				}
+			}
+
+			summaries[i] = repo.addRelations(entity, summary, stored, depth, path)
+		}
+
+		return summaries
+
+	default:
+		records := make([]map[string]interface{}, len(related))
+		for i, stored := range related {
+			records[i] = repo.addRelations(entity, copyRecord(stored), stored, depth, path)
+		}
+
+		return records
+	}
+}
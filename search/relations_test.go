@@ -0,0 +1,329 @@
+package search_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+func newRelationsTestRepository(t *testing.T) *search.SearchRepository {
+	users, err := search.NewUserJSONRepository([]map[string]interface{}{
+		{"_id": float64(1), "name": "user 1", "organization_id": float64(22)},
+		{"_id": float64(2), "name": "user 2"},
+	})
+	require.Nil(t, err)
+
+	orgs, err := search.NewOrgJSONRepository([]map[string]interface{}{
+		{"_id": float64(22), "name": "org 1"},
+	})
+	require.Nil(t, err)
+
+	tickets, err := search.NewTicketJSONRepository([]map[string]interface{}{
+		{"_id": "abcd", "subject": "ticket 1", "submitter_id": float64(2), "assignee_id": float64(1)},
+	})
+	require.Nil(t, err)
+
+	return search.NewSearchRepository(users, orgs, tickets)
+}
+
+func Test_SearchRepository_SetExpandOptions(t *testing.T) {
+	repo := newRelationsTestRepository(t)
+
+	//by default every relation is added one level deep
+	ticket := repo.Find(search.TicketsGroup, "_id", "abcd")[0]
+	require.Equal(t, map[string]interface{}{"_id": float64(1), "name": "user 1", "organization_id": float64(22)}, ticket["assigned_user"])
+	require.Equal(t, map[string]interface{}{"_id": float64(2), "name": "user 2"}, ticket["submitted_user"])
+	require.NotContains(t, ticket, "organization")
+
+	repo.SetExpandOptions(search.ExpandOptions{Depth: 0})
+	require.Equal(t, []map[string]interface{}{
+		{"_id": "abcd", "subject": "ticket 1", "submitter_id": float64(2), "assignee_id": float64(1)},
+	}, repo.Find(search.TicketsGroup, "_id", "abcd"))
+
+	//ticket -> assignee -> organization
+	repo.SetExpandOptions(search.ExpandOptions{Relations: []string{"assigned_user", "organization"}, Depth: 2})
+	require.Equal(t, []map[string]interface{}{
+		{
+			"_id":          "abcd",
+			"subject":      "ticket 1",
+			"submitter_id": float64(2),
+			"assignee_id":  float64(1),
+			"assigned_user": map[string]interface{}{
+				"_id":             float64(1),
+				"name":            "user 1",
+				"organization_id": float64(22),
+				"organization":    map[string]interface{}{"_id": float64(22), "name": "org 1"},
+			},
+		},
+	}, repo.Find(search.TicketsGroup, "_id", "abcd"))
+
+	repo.SetExpandOptions(search.ExpandOptions{Relations: []string{"users"}, Depth: 1})
+	require.Equal(t, []map[string]interface{}{
+		{
+			"_id":   float64(22),
+			"name":  "org 1",
+			"users": []map[string]interface{}{{"_id": float64(1), "name": "user 1", "organization_id": float64(22)}},
+		},
+	}, repo.Find(search.OrganizationsGroup, "_id", float64(22)))
+}
+
+func Test_SearchRepository_RelationModes(t *testing.T) {
+	repo := newRelationsTestRepository(t)
+
+	repo.SetExpandOptions(search.ExpandOptions{Depth: 1, Mode: search.RelationsSummary})
+	user := repo.Find(search.UsersGroup, "_id", float64(1))[0]
+	require.Equal(t, map[string]interface{}{"_id": float64(22), "name": "org 1"}, user["organization"])
+	require.Equal(t, []map[string]interface{}{{"_id": "abcd", "subject": "ticket 1"}}, user["assigned_tickets"])
+	require.Equal(t, []map[string]interface{}{}, user["submitted_tickets"])
+
+	//summaries still get their own relations when the depth allows it
+	repo.SetExpandOptions(search.ExpandOptions{Relations: []string{"assigned_user", "organization"}, Depth: 2, Mode: search.RelationsSummary})
+	require.Equal(t, map[string]interface{}{
+		"_id":          float64(1),
+		"name":         "user 1",
+		"organization": map[string]interface{}{"_id": float64(22), "name": "org 1"},
+	}, repo.Find(search.TicketsGroup, "_id", "abcd")[0]["assigned_user"])
+
+	repo.SetExpandOptions(search.ExpandOptions{
+		Depth:         1,
+		Mode:          search.RelationsSummary,
+		SummaryFields: map[string][]string{search.UsersGroup: {"name", "organization_id"}},
+	})
+	require.Equal(t, map[string]interface{}{"name": "user 1", "organization_id": float64(22)}, repo.Find(search.TicketsGroup, "_id", "abcd")[0]["assigned_user"])
+
+	repo.SetExpandOptions(search.ExpandOptions{Depth: 2, Mode: search.RelationsIDs})
+	ticket := repo.Find(search.TicketsGroup, "_id", "abcd")[0]
+	require.Equal(t, float64(1), ticket["assigned_user"])
+	require.Equal(t, float64(2), ticket["submitted_user"])
+	require.Equal(t, []interface{}{"abcd"}, repo.Find(search.UsersGroup, "_id", float64(1))[0]["assigned_tickets"])
+}
+
+func Test_ValidateExpandOptions(t *testing.T) {
+	require.Nil(t, search.ValidateExpandOptions(search.DefaultSchema, search.UsersGroup, search.DefaultExpandOptions))
+	require.Nil(t, search.ValidateExpandOptions(search.DefaultSchema, search.UsersGroup, search.ExpandOptions{Depth: 0}))
+	require.Nil(t, search.ValidateExpandOptions(search.DefaultSchema, search.TicketsGroup, search.ExpandOptions{Relations: []string{"assigned_user", "organization"}, Depth: 2}))
+
+	err := search.ValidateExpandOptions(search.DefaultSchema, search.UsersGroup, search.ExpandOptions{Relations: []string{"assigned_user"}, Depth: 1})
+	require.EqualError(t, err, `Invalid relation "assigned_user" for users with a depth of 1, expected one of: assigned_tickets, comments, groups, organization, submitted_tickets`)
+
+	//assigned_user can only be reached through the tickets, so they have to be expanded too
+	require.NotNil(t, search.ValidateExpandOptions(search.DefaultSchema, search.UsersGroup, search.ExpandOptions{Relations: []string{"assigned_user"}, Depth: 2}))
+	require.Nil(t, search.ValidateExpandOptions(search.DefaultSchema, search.UsersGroup, search.ExpandOptions{Relations: []string{"assigned_tickets", "assigned_user"}, Depth: 2}))
+
+	require.EqualError(t, search.ValidateExpandOptions(search.DefaultSchema, search.UsersGroup, search.ExpandOptions{Depth: -1}), "Invalid depth -1, it can't be negative")
+	require.EqualError(t, search.ValidateExpandOptions(search.DefaultSchema, search.UsersGroup, search.ExpandOptions{Depth: 1, Mode: "all"}), `Invalid relation mode "all", expected one of: full, summary, ids`)
+}
+
+func Test_SearchRepository_Stream(t *testing.T) {
+	repo := newRelationsTestRepository(t)
+
+	var streamed []map[string]interface{}
+	err := repo.Stream(search.UsersGroup, "name", "user 1", func(record map[string]interface{}) error {
+		streamed = append(streamed, record)
+		return nil
+	})
+
+	require.Nil(t, err)
+	require.Equal(t, repo.Find(search.UsersGroup, "name", "user 1"), streamed)
+
+	//an error from the handler stops the stream
+	calls := 0
+	stopErr := errors.New("stop")
+	err = repo.StreamQuery(search.UsersGroup, &search.OrNode{
+		Left:  &search.TermNode{Field: "_id", Value: "1"},
+		Right: &search.TermNode{Field: "_id", Value: "2"},
+	}, func(record map[string]interface{}) error {
+		calls++
+		return stopErr
+	})
+
+	require.Equal(t, stopErr, err)
+	require.Equal(t, 1, calls)
+}
+
+func Test_SearchRepository_Fields(t *testing.T) {
+	repo := newRelationsTestRepository(t)
+
+	repo.SetExpandOptions(search.ExpandOptions{Fields: []string{"_id", "assigned_user.organization.name"}, Depth: 2})
+	require.Equal(t, []map[string]interface{}{
+		{
+			"_id": "abcd",
+			"assigned_user": map[string]interface{}{
+				"organization": map[string]interface{}{"name": "org 1"},
+			},
+		},
+	}, repo.Find(search.TicketsGroup, "_id", "abcd"))
+
+	repo.SetExpandOptions(search.ExpandOptions{Fields: []string{"name", "assigned_tickets.subject"}, Depth: 1})
+	require.Equal(t, []map[string]interface{}{
+		{"name": "user 1", "assigned_tickets": []map[string]interface{}{{"subject": "ticket 1"}}},
+	}, repo.Find(search.UsersGroup, "_id", float64(1)))
+}
+
+func Test_SearchRepository_Fields_SkipsRelations(t *testing.T) {
+	usersRepo := new(OrgUserMockRepo)
+	orgsRepo := new(OrgUserMockRepo)
+	ticketsRepo := new(TicketMockRepo)
+
+	repo := search.NewSearchRepository(usersRepo, orgsRepo, ticketsRepo)
+
+	ticket := map[string]interface{}{"_id": "abcd", "subject": "ticket 1", "assignee_id": float64(1), "organization_id": float64(22)}
+	ticketsRepo.On("FindByField", "_id", "abcd").Return([]map[string]interface{}{ticket})
+	usersRepo.On("FindByID", float64(1)).Return(map[string]interface{}{"_id": float64(1), "name": "user 1"})
+
+	//only the assigned user is needed, so the organization is never looked up
+	repo.SetExpandOptions(search.ExpandOptions{Fields: []string{"subject", "assigned_user.name"}, Depth: 1})
+	require.Equal(t, []map[string]interface{}{
+		{"subject": "ticket 1", "assigned_user": map[string]interface{}{"name": "user 1"}},
+	}, repo.Find(search.TicketsGroup, "_id", "abcd"))
+
+	orgsRepo.AssertNotCalled(t, "FindByID", float64(22))
+	usersRepo.AssertNumberOfCalls(t, "FindByID", 1)
+}
+
+func Test_FieldsDepth(t *testing.T) {
+	require.Equal(t, 0, search.FieldsDepth(search.DefaultSchema, search.TicketsGroup, []string{"_id", "subject"}))
+	require.Equal(t, 1, search.FieldsDepth(search.DefaultSchema, search.TicketsGroup, []string{"_id", "organization.name"}))
+	require.Equal(t, 2, search.FieldsDepth(search.DefaultSchema, search.TicketsGroup, []string{"organization", "assigned_user.organization.name"}))
+}
+
+func newEntityRelationsTestRepository(t *testing.T) *search.SearchRepository {
+	repo := newRelationsTestRepository(t)
+
+	groups, err := search.NewEntityRepository([]map[string]interface{}{
+		{"_id": float64(5), "name": "Support", "user_ids": []interface{}{float64(1), float64(2)}},
+		{"_id": float64(6), "name": "Billing", "user_ids": []interface{}{float64(2)}},
+	}, search.DefaultSchema.Entity(search.GroupsGroup))
+	require.Nil(t, err)
+
+	comments, err := search.NewEntityRepository([]map[string]interface{}{
+		{"_id": float64(10), "ticket_id": "abcd", "author_id": float64(2), "body": "the printer is on fire"},
+		{"_id": float64(11), "ticket_id": "abcd", "author_id": float64(1), "body": "have you tried turning it off"},
+	}, search.DefaultSchema.Entity(search.TicketCommentsGroup))
+	require.Nil(t, err)
+
+	repo.AddEntityRepository(search.GroupsGroup, groups)
+	repo.AddEntityRepository(search.TicketCommentsGroup, comments)
+
+	return repo
+}
+
+func Test_SearchRepository_EntityRelations(t *testing.T) {
+	repo := newEntityRelationsTestRepository(t)
+	repo.SetExpandOptions(search.ExpandOptions{Depth: 1, Mode: search.RelationsIDs})
+
+	user := repo.Find(search.UsersGroup, "_id", float64(2))[0]
+	require.Equal(t, []interface{}{float64(5), float64(6)}, user["groups"])
+	require.Equal(t, []interface{}{float64(10)}, user["comments"])
+
+	ticket := repo.Find(search.TicketsGroup, "_id", "abcd")[0]
+	require.Equal(t, []interface{}{float64(10), float64(11)}, ticket["comments"])
+
+	//satisfaction ratings weren't loaded, so the relation is left out instead of being empty
+	require.NotContains(t, ticket, "satisfaction_rating")
+
+	group := repo.Find(search.GroupsGroup, "_id", "5")[0]
+	require.Equal(t, []interface{}{float64(1), float64(2)}, group["users"])
+
+	comment := repo.Find(search.TicketCommentsGroup, "author_id", float64(1))[0]
+	require.Equal(t, "abcd", comment["ticket"])
+	require.Equal(t, float64(1), comment["author"])
+}
+
+func Test_SearchRepository_EntitySearches(t *testing.T) {
+	repo := newEntityRelationsTestRepository(t)
+	repo.SetExpandOptions(search.ExpandOptions{Depth: 0})
+
+	require.Equal(t, []map[string]interface{}{
+		{"_id": float64(6), "name": "Billing", "user_ids": []interface{}{float64(2)}},
+	}, repo.Query(search.GroupsGroup, &search.TermNode{Field: "name", Value: "Billing"}))
+
+	comments := repo.FindByText(search.TicketCommentsGroup, "printer", false)
+	require.Len(t, comments, 1)
+	require.Equal(t, float64(10), comments[0]["_id"])
+
+	//types without records or a text index find nothing
+	require.Empty(t, repo.Find(search.SatisfactionRatingsGroup, "score", "good"))
+	require.Empty(t, repo.FindByText(search.SatisfactionRatingsGroup, "great", false))
+}
+
+func Test_Schema_Relations(t *testing.T) {
+	var names []string
+	for _, relation := range search.DefaultSchema.Relations(search.UsersGroup) {
+		names = append(names, relation.Name)
+	}
+	require.Equal(t, []string{"organization", "submitted_tickets", "assigned_tickets", "groups", "comments"}, names)
+
+	ticketRelations := search.DefaultSchema.Relations(search.TicketsGroup)
+	rating := withoutKeyType(ticketRelations[len(ticketRelations)-1])
+	require.Equal(t, search.Relation{Name: "satisfaction_rating", From: search.TicketsGroup, To: search.SatisfactionRatingsGroup, Field: "ticket_id", Backward: true}, rating)
+}
+
+//withoutKeyType copies the exported fields of the relation so it can be compared to one built in the test
+func withoutKeyType(relation search.Relation) search.Relation {
+	return search.Relation{Name: relation.Name, From: relation.From, To: relation.To, Many: relation.Many, Field: relation.Field, Backward: relation.Backward}
+}
+
+func Test_SearchRepository_SchemaRelations(t *testing.T) {
+	//a reference field added by a schema file, pointing at the string IDs of the tickets
+	schema, err := search.ParseSchema([]byte(`{"users": [
+		{"name": "_id", "type": "int"},
+		{"name": "name", "type": "string"},
+		{"name": "pinned_ticket_id", "type": "reference", "target": "tickets", "inverse": "pinned_by"}
+	]}`), search.DefaultSchema)
+	require.Nil(t, err)
+
+	users, err := search.NewEntityRepository([]map[string]interface{}{
+		{"_id": float64(1), "name": "user 1", "pinned_ticket_id": "abcd"},
+		{"_id": float64(2), "name": "user 2"},
+	}, schema.Entity(search.UsersGroup))
+	require.Nil(t, err)
+
+	tickets, err := search.NewEntityRepository([]map[string]interface{}{
+		{"_id": "abcd", "subject": "ticket 1"},
+	}, schema.Entity(search.TicketsGroup))
+	require.Nil(t, err)
+
+	repo := search.NewSearchRepositoryFromEntities(map[string]*search.EntityRepository{
+		search.UsersGroup:   users,
+		search.TicketsGroup: tickets,
+	})
+	repo.SetExpandOptions(search.ExpandOptions{Depth: 1, Mode: search.RelationsIDs})
+
+	require.Equal(t, "abcd", repo.Find(search.UsersGroup, "_id", float64(1))[0]["pinned_ticket"])
+	require.NotContains(t, repo.Find(search.UsersGroup, "_id", float64(2))[0], "pinned_ticket")
+	require.Equal(t, []interface{}{float64(1)}, repo.Find(search.TicketsGroup, "_id", "abcd")[0]["pinned_by"])
+}
+
+func Test_SearchRepository_Stream_Order(t *testing.T) {
+	users, err := search.NewEntityRepository([]map[string]interface{}{
+		{"_id": float64(3), "role": "admin"},
+		{"_id": float64(1), "role": "admin"},
+		{"_id": float64(2), "role": "admin"},
+	}, search.DefaultSchema.Entity(search.UsersGroup))
+	require.Nil(t, err)
+
+	repo := search.NewSearchRepositoryFromEntities(map[string]*search.EntityRepository{search.UsersGroup: users})
+
+	streamedIDs := func() []interface{} {
+		var ids []interface{}
+		err := repo.Stream(search.UsersGroup, "role", "admin", func(record map[string]interface{}) error {
+			ids = append(ids, record["_id"])
+			return nil
+		})
+		require.Nil(t, err)
+
+		return ids
+	}
+
+	//without a sort or page the records come in the order they were loaded
+	require.Equal(t, []interface{}{float64(3), float64(1), float64(2)}, streamedIDs())
+
+	repo.SetPageOptions(search.PageOptions{Sort: search.DefaultSort})
+	require.Equal(t, []interface{}{float64(1), float64(2), float64(3)}, streamedIDs())
+
+	repo.SetPageOptions(search.PageOptions{Limit: 2})
+	require.Equal(t, []interface{}{float64(1), float64(2)}, streamedIDs())
+}
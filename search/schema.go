@@ -0,0 +1,417 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//FieldType tells the search how to read a field's values and the search terms typed in for it
+type FieldType string
+
+//field types that can be used in a schema
+const (
+	FieldInt        FieldType = "int"
+	FieldString     FieldType = "string"
+	FieldBool       FieldType = "bool"
+	FieldDateTime   FieldType = "datetime"
+	FieldStringList FieldType = "string-list"
+	FieldReference  FieldType = "reference"
+	//FieldReferenceList holds a list of IDs, like the members of a group
+	FieldReferenceList FieldType = "reference-list"
+)
+
+//FieldTypes lists every FieldType
+var FieldTypes = []FieldType{FieldInt, FieldString, FieldBool, FieldDateTime, FieldStringList, FieldReference, FieldReferenceList}
+
+//FieldSchema describes one field of a type of record. Indexed fields are searched by the text commands,
+//and Target is the type of record a reference field holds the "_id" of.
+//The records a reference field points at are added to results under Relation, which defaults to the field name
+//without "_id", or with "s" in place of "_ids" for lists. Inverse names the relation that adds the records holding
+//the reference to the target, and InverseOne makes it a single record rather than a list
+type FieldSchema struct {
+	Name       string    `json:"name"`
+	Type       FieldType `json:"type"`
+	Indexed    bool      `json:"indexed,omitempty"`
+	Target     string    `json:"target,omitempty"`
+	Relation   string    `json:"relation,omitempty"`
+	Inverse    string    `json:"inverse,omitempty"`
+	InverseOne bool      `json:"inverse_one,omitempty"`
+
+	keyType FieldType //the type of the target's "_id", filled in by ParseSchema
+}
+
+//valueType is the type the field's values actually have, which for references is the type of the ID they point to
+func (field FieldSchema) valueType() FieldType {
+	switch field.Type {
+	case FieldReference:
+		return field.keyType
+	case FieldReferenceList:
+		return FieldStringList
+	default:
+		return field.Type
+	}
+}
+
+//IsReference is true for fields that hold the IDs of other records
+func (field FieldSchema) IsReference() bool {
+	return field.Type == FieldReference || field.Type == FieldReferenceList
+}
+
+//Numeric is true for fields that can be searched by range
+func (field FieldSchema) Numeric() bool {
+	return field.valueType() == FieldInt
+}
+
+//ParseTerm turns a search term typed in for the field into the value the repositories search for.
+//Numeric fields understand ranges like 10..25, and terms that don't fit the field's type are an error.
+//An empty term is kept as it is, since it finds the records with the field empty
+func (field FieldSchema) ParseTerm(term string) (interface{}, error) {
+	if term == "" {
+		return term, nil
+	}
+
+	switch field.valueType() {
+	case FieldInt:
+		if IsRangeTerm(term) {
+			return ParseNumericRange(term)
+		}
+
+		value, err := strconv.ParseFloat(term, 64)
+		if err != nil {
+			return nil, fmt.Errorf(`Invalid value "%v" for %v, expected a number`, term, field.Name)
+		}
+
+		return value, nil
+	case FieldBool:
+		value, isBool := boolVal(term)
+		if !isBool {
+			return nil, fmt.Errorf(`Invalid value "%v" for %v, expected true or false`, term, field.Name)
+		}
+
+		return value, nil
+	default:
+		return term, nil
+	}
+}
+
+//EntitySchema lists the fields of one type of record in the order they are shown
+type EntitySchema struct {
+	Name   string
+	Fields []FieldSchema
+}
+
+//FieldNames lists the names of the fields in order
+func (schema EntitySchema) FieldNames() []string {
+	names := make([]string, len(schema.Fields))
+	for i, field := range schema.Fields {
+		names[i] = field.Name
+	}
+
+	return names
+}
+
+//Field looks up a field by name
+func (schema EntitySchema) Field(name string) (FieldSchema, bool) {
+	for _, field := range schema.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+
+	return FieldSchema{}, false
+}
+
+//FieldsOfType lists the names of the fields that have one of the types. References count as the type of ID they hold
+func (schema EntitySchema) FieldsOfType(types ...FieldType) []string {
+	var names []string
+	for _, field := range schema.Fields {
+		for _, fieldType := range types {
+			if field.Type == fieldType || field.valueType() == fieldType {
+				names = append(names, field.Name)
+				break
+			}
+		}
+	}
+
+	return names
+}
+
+//IndexedFields lists the fields searched by the text commands
+func (schema EntitySchema) IndexedFields() []string {
+	var names []string
+	for _, field := range schema.Fields {
+		if field.Indexed {
+			names = append(names, field.Name)
+		}
+	}
+
+	return names
+}
+
+//Schema holds the EntitySchema of every type of record, by name
+type Schema map[string]EntitySchema
+
+//Entity returns the schema of one type of record. Unknown types have no fields
+func (schema Schema) Entity(name string) EntitySchema {
+	return schema[name]
+}
+
+//ParseSchema reads a schema file. The file is a JSON object with a list of fields for each type of record, like
+//{"users": [{"name": "_id", "type": "int"}, {"name": "signature", "type": "string", "indexed": true}]}.
+//Types of record that aren't in the file keep the fields they have in base
+func ParseSchema(data []byte, base Schema) (Schema, error) {
+	var fileFields map[string][]FieldSchema
+	if err := json.Unmarshal(data, &fileFields); err != nil {
+		return nil, errors.WithMessage(err, "Expected a JSON object with a list of fields for each type of record")
+	}
+
+	schema := make(Schema, len(base)+len(fileFields))
+	for name, entity := range base {
+		schema[name] = entity
+	}
+
+	for name, fields := range fileFields {
+		schema[name] = EntitySchema{Name: name, Fields: fields}
+	}
+
+	if err := schema.resolve(); err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+//resolve checks every field and fills in the key type of the reference fields
+func (schema Schema) resolve() error {
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		entity := schema[name]
+
+		idField, hasID := entity.Field("_id")
+		if !hasID {
+			return fmt.Errorf(`The schema for %v is missing the "_id" field`, name)
+		}
+
+		if idField.Type != FieldInt && idField.Type != FieldString {
+			return fmt.Errorf(`The "_id" field of %v must be an int or a string`, name)
+		}
+
+		fields := make([]FieldSchema, len(entity.Fields))
+		seen := map[string]bool{}
+
+		for i, field := range entity.Fields {
+			if field.Name == "" {
+				return fmt.Errorf("A field of %v is missing its name", name)
+			}
+
+			if seen[field.Name] {
+				return fmt.Errorf(`The field "%v" of %v is listed twice`, field.Name, name)
+			}
+
+			seen[field.Name] = true
+
+			if !fieldTypeValid(field.Type) {
+				return fmt.Errorf(`Invalid type "%v" for the field "%v" of %v, expected one of: %v`, field.Type, field.Name, name, fieldTypeList())
+			}
+
+			if field.IsReference() {
+				target, hasTarget := schema[field.Target]
+				if !hasTarget {
+					return fmt.Errorf(`The reference field "%v" of %v has an unknown target "%v"`, field.Name, name, field.Target)
+				}
+
+				targetID, _ := target.Field("_id")
+				field.keyType = targetID.Type
+			} else if field.Target != "" || field.Relation != "" || field.Inverse != "" {
+				return fmt.Errorf(`The field "%v" of %v has a target or relation but isn't a reference`, field.Name, name)
+			}
+
+			fields[i] = field
+		}
+
+		schema[name] = EntitySchema{Name: name, Fields: fields}
+	}
+
+	for _, name := range names {
+		if err := schema.checkRelations(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//checkRelations makes sure every relation of the type of record has a name of its own, since they are added to results next to its fields
+func (schema Schema) checkRelations(name string) error {
+	entity := schema[name]
+	seen := map[string]bool{}
+
+	for _, relation := range schema.Relations(name) {
+		if _, isField := entity.Field(relation.Name); isField {
+			return fmt.Errorf(`The relation "%v" of %v has the same name as a field, give the reference field a "relation" or "inverse" name`, relation.Name, name)
+		}
+
+		if seen[relation.Name] {
+			return fmt.Errorf(`The relation "%v" of %v is defined twice`, relation.Name, name)
+		}
+
+		seen[relation.Name] = true
+	}
+
+	return nil
+}
+
+//Names lists the types of record in the schema, the ones in EntityGroups first in that order and then the rest alphabetically
+func (schema Schema) Names() []string {
+	names := make([]string, 0, len(schema))
+	known := map[string]bool{}
+
+	for _, name := range EntityGroups {
+		known[name] = true
+		if _, inSchema := schema[name]; inSchema {
+			names = append(names, name)
+		}
+	}
+
+	var others []string
+	for name := range schema {
+		if !known[name] {
+			others = append(others, name)
+		}
+	}
+
+	sort.Strings(others)
+	return append(names, others...)
+}
+
+func fieldTypeValid(fieldType FieldType) bool {
+	for _, validType := range FieldTypes {
+		if fieldType == validType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func fieldTypeList() string {
+	types := make([]string, len(FieldTypes))
+	for i, fieldType := range FieldTypes {
+		types[i] = string(fieldType)
+	}
+
+	return strings.Join(types, ", ")
+}
+
+//MarshalJSON writes the schema in the same format ParseSchema reads
+func (schema Schema) MarshalJSON() ([]byte, error) {
+	fileFields := make(map[string][]FieldSchema, len(schema))
+	for name, entity := range schema {
+		fileFields[name] = entity.Fields
+	}
+
+	return json.Marshal(fileFields)
+}
+
+//DefaultSchema describes the fields of the zendesk exports in the data folder
+var DefaultSchema = mustParseSchema(defaultSchemaJSON)
+
+func mustParseSchema(data string) Schema {
+	schema, err := ParseSchema([]byte(data), nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return schema
+}
+
+const defaultSchemaJSON = `{
+  "users": [
+    {"name": "_id", "type": "int"},
+    {"name": "url", "type": "string"},
+    {"name": "external_id", "type": "string"},
+    {"name": "name", "type": "string"},
+    {"name": "alias", "type": "string", "indexed": true},
+    {"name": "created_at", "type": "datetime"},
+    {"name": "active", "type": "bool"},
+    {"name": "verified", "type": "bool"},
+    {"name": "shared", "type": "bool"},
+    {"name": "locale", "type": "string"},
+    {"name": "timezone", "type": "string"},
+    {"name": "last_login_at", "type": "datetime"},
+    {"name": "email", "type": "string"},
+    {"name": "phone", "type": "string"},
+    {"name": "signature", "type": "string", "indexed": true},
+    {"name": "organization_id", "type": "reference", "target": "organizations", "inverse": "users"},
+    {"name": "tags", "type": "string-list"},
+    {"name": "suspended", "type": "bool"},
+    {"name": "role", "type": "string"}
+  ],
+  "organizations": [
+    {"name": "_id", "type": "int"},
+    {"name": "url", "type": "string"},
+    {"name": "external_id", "type": "string"},
+    {"name": "name", "type": "string"},
+    {"name": "domain_names", "type": "string-list"},
+    {"name": "created_at", "type": "datetime"},
+    {"name": "details", "type": "string"},
+    {"name": "shared_tickets", "type": "bool"},
+    {"name": "tags", "type": "string-list"}
+  ],
+  "tickets": [
+    {"name": "_id", "type": "string"},
+    {"name": "url", "type": "string"},
+    {"name": "external_id", "type": "string"},
+    {"name": "created_at", "type": "datetime"},
+    {"name": "type", "type": "string"},
+    {"name": "subject", "type": "string", "indexed": true},
+    {"name": "description", "type": "string", "indexed": true},
+    {"name": "priority", "type": "string"},
+    {"name": "status", "type": "string"},
+    {"name": "submitter_id", "type": "reference", "target": "users", "relation": "submitted_user", "inverse": "submitted_tickets"},
+    {"name": "assignee_id", "type": "reference", "target": "users", "relation": "assigned_user", "inverse": "assigned_tickets"},
+    {"name": "organization_id", "type": "reference", "target": "organizations", "inverse": "tickets"},
+    {"name": "tags", "type": "string-list"},
+    {"name": "has_incidents", "type": "bool"},
+    {"name": "due_at", "type": "datetime"},
+    {"name": "via", "type": "string"}
+  ],
+  "groups": [
+    {"name": "_id", "type": "int"},
+    {"name": "url", "type": "string"},
+    {"name": "name", "type": "string"},
+    {"name": "description", "type": "string", "indexed": true},
+    {"name": "created_at", "type": "datetime"},
+    {"name": "default", "type": "bool"},
+    {"name": "user_ids", "type": "reference-list", "target": "users", "inverse": "groups"}
+  ],
+  "ticket_comments": [
+    {"name": "_id", "type": "int"},
+    {"name": "url", "type": "string"},
+    {"name": "ticket_id", "type": "reference", "target": "tickets", "inverse": "comments"},
+    {"name": "author_id", "type": "reference", "target": "users", "inverse": "comments"},
+    {"name": "body", "type": "string", "indexed": true},
+    {"name": "public", "type": "bool"},
+    {"name": "created_at", "type": "datetime"},
+    {"name": "via", "type": "string"}
+  ],
+  "satisfaction_ratings": [
+    {"name": "_id", "type": "int"},
+    {"name": "url", "type": "string"},
+    {"name": "ticket_id", "type": "reference", "target": "tickets", "inverse": "satisfaction_rating", "inverse_one": true},
+    {"name": "score", "type": "string"},
+    {"name": "comment", "type": "string", "indexed": true},
+    {"name": "created_at", "type": "datetime"}
+  ]
+}`
@@ -0,0 +1,113 @@
+package search_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+func Test_ParseSchema(t *testing.T) {
+	schema, err := search.ParseSchema([]byte(`{"organizations": [
+		{"name": "_id", "type": "int"},
+		{"name": "name", "type": "string"},
+		{"name": "renewed_at", "type": "datetime"}
+	]}`), search.DefaultSchema)
+	require.Nil(t, err)
+
+	require.Equal(t, []string{"_id", "name", "renewed_at"}, schema.Entity(search.OrganizationsGroup).FieldNames())
+	require.Equal(t, search.DefaultSchema.Entity(search.UsersGroup).FieldNames(), schema.Entity(search.UsersGroup).FieldNames())
+
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{name: "not an object", schema: `[]`},
+		{name: "missing _id", schema: `{"users": [{"name": "name", "type": "string"}]}`},
+		{name: "unknown type", schema: `{"users": [{"name": "_id", "type": "int"}, {"name": "score", "type": "float"}]}`},
+		{name: "unknown target", schema: `{"users": [{"name": "_id", "type": "int"}, {"name": "brand_id", "type": "reference", "target": "brands"}]}`},
+		{name: "field listed twice", schema: `{"users": [{"name": "_id", "type": "int"}, {"name": "_id", "type": "string"}]}`},
+		{name: "relation on a plain field", schema: `{"users": [{"name": "_id", "type": "int"}, {"name": "name", "type": "string", "relation": "owner"}]}`},
+		{name: "relation named like a field", schema: `{"users": [{"name": "_id", "type": "int"}, {"name": "manager", "type": "reference", "target": "users"}]}`},
+		{name: "relation defined twice", schema: `{"users": [{"name": "_id", "type": "int"}, {"name": "organization_id", "type": "reference", "target": "organizations"}, {"name": "org_id", "type": "reference", "target": "organizations", "relation": "organization"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := search.ParseSchema([]byte(tt.schema), search.DefaultSchema)
+			require.NotNil(t, err)
+		})
+	}
+}
+
+func Test_EntitySchema_FieldsOfType(t *testing.T) {
+	tickets := search.DefaultSchema.Entity(search.TicketsGroup)
+
+	require.Equal(t, []string{"created_at", "due_at"}, tickets.FieldsOfType(search.FieldDateTime))
+	require.Equal(t, []string{"submitter_id", "assignee_id", "organization_id"}, tickets.FieldsOfType(search.FieldInt))
+	require.Equal(t, []string{"subject", "description"}, tickets.IndexedFields())
+}
+
+func Test_FieldSchema_ParseTerm(t *testing.T) {
+	users := search.DefaultSchema.Entity(search.UsersGroup)
+	tickets := search.DefaultSchema.Entity(search.TicketsGroup)
+
+	tests := []struct {
+		name         string
+		schema       search.EntitySchema
+		field        string
+		term         string
+		expectedTerm interface{}
+		expectError  bool
+	}{
+		{name: "number", schema: users, field: "_id", term: "71", expectedTerm: float64(71)},
+		{name: "range", schema: users, field: "_id", term: "10..25", expectedTerm: search.NumericRange{Min: 10, Max: 25, MinInclusive: true, MaxInclusive: true}},
+		{name: "not a number", schema: users, field: "_id", term: "abc", expectError: true},
+		{name: "reference to a number", schema: users, field: "organization_id", term: "119", expectedTerm: float64(119)},
+		{name: "bool", schema: users, field: "active", term: "true", expectedTerm: true},
+		{name: "not a bool", schema: users, field: "active", term: "maybe", expectError: true},
+		{name: "string with range syntax", schema: tickets, field: "subject", term: "1..2", expectedTerm: "1..2"},
+		{name: "string id", schema: tickets, field: "_id", term: "436bf9b0", expectedTerm: "436bf9b0"},
+		{name: "empty", schema: users, field: "_id", term: "", expectedTerm: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field, _ := tt.schema.Field(tt.field)
+
+			term, err := field.ParseTerm(tt.term)
+			if tt.expectError {
+				require.NotNil(t, err)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, tt.expectedTerm, term)
+		})
+	}
+}
+
+func Test_NewOrgJSONRepositoryWithSchema(t *testing.T) {
+	schema, err := search.ParseSchema([]byte(`{"organizations": [
+		{"name": "_id", "type": "int"},
+		{"name": "renewed_at", "type": "datetime"},
+		{"name": "seats", "type": "int"}
+	]}`), search.DefaultSchema)
+	require.Nil(t, err)
+
+	orgList := []map[string]interface{}{
+		{"_id": float64(1), "renewed_at": "2016-01-10T10:00:00 -10:00", "seats": float64(5)},
+		{"_id": float64(2), "renewed_at": "2016-08-10T10:00:00 -10:00", "seats": float64(50)},
+	}
+
+	orgs, err := search.NewOrgJSONRepositoryWithSchema(orgList, schema.Entity(search.OrganizationsGroup))
+	require.Nil(t, err)
+
+	june := time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, []map[string]interface{}{orgList[1]}, orgs.FindByField("renewed_at", search.DateRange{From: june}))
+	require.Equal(t, []map[string]interface{}{orgList[1]}, orgs.FindByField("seats", search.NumericRange{Min: 10, Max: 100, MinInclusive: true}))
+
+	_, err = search.NewOrgJSONRepositoryWithSchema([]map[string]interface{}{{"_id": float64(1), "renewed_at": "soon"}}, schema.Entity(search.OrganizationsGroup))
+	require.NotNil(t, err)
+}
@@ -4,11 +4,14 @@ type UserRepository interface {
 	FindByID(userID float64) map[string]interface{}
 	FindByOrg(orgID float64) []map[string]interface{}
 	FindByField(fieldName string, searchVal interface{}) []map[string]interface{}
+	FindAll() []map[string]interface{}
+	FindByText(query string) []TextMatch
 }
 
 type OrgRepository interface {
 	FindByID(orgID float64) map[string]interface{}
 	FindByField(fieldName string, searchVal interface{}) []map[string]interface{}
+	FindAll() []map[string]interface{}
 }
 
 type TicketRepository interface {
@@ -17,84 +20,306 @@ type TicketRepository interface {
 	FindBySubmitter(userID float64) []map[string]interface{}
 	FindByOrg(orgID float64) []map[string]interface{}
 	FindByField(fieldName string, searchVal interface{}) []map[string]interface{}
+	FindAll() []map[string]interface{}
+	FindByText(query string) []TextMatch
 }
 
 type SearchRepository struct {
 	userRepository   UserRepository
 	orgRepository    OrgRepository
 	ticketRepository TicketRepository
+	//entityRepositories holds the types of record loaded with NewEntityRepository, like groups
+	entityRepositories map[string]*EntityRepository
+	relations          map[string][]Relation //the relations of each type of record, worked out from the schemas when first needed
+	valueMatcher       ValueMatcher          //used when searching every field at once
+	expandOptions      ExpandOptions
+	pageOptions        PageOptions
 }
 
 func NewSearchRepository(users UserRepository, orgs OrgRepository, tickets TicketRepository) *SearchRepository {
 	return &SearchRepository{
-		userRepository:   users,
-		orgRepository:    orgs,
-		ticketRepository: tickets,
+		userRepository:     users,
+		orgRepository:      orgs,
+		ticketRepository:   tickets,
+		entityRepositories: make(map[string]*EntityRepository),
+		valueMatcher:       SearchValueMatches,
+		expandOptions:      DefaultExpandOptions,
 	}
 }
 
-func (repo *SearchRepository) findOrgRelation(item map[string]interface{}) map[string]interface{} {
-	if orgID, isFloat := item["organization_id"].(float64); isFloat {
-		return repo.orgRepository.FindByID(orgID)
+//NewSearchRepositoryFromEntities searches records that were all loaded with NewEntityRepository, by type of record
+func NewSearchRepositoryFromEntities(repositories map[string]*EntityRepository) *SearchRepository {
+	repo := NewSearchRepository(nil, nil, nil)
+	for entity, records := range repositories {
+		repo.AddEntityRepository(entity, records)
+	}
+
+	return repo
+}
+
+//AddEntityRepository adds another type of record, like GroupsGroup. Its relations are added to results once it is loaded
+func (repo *SearchRepository) AddEntityRepository(entity string, records *EntityRepository) {
+	repo.entityRepositories[entity] = records
+	repo.relations = nil
+}
+
+//finder returns the repository of the type of record, or nil if it wasn't loaded
+func (repo *SearchRepository) finder(entity string) RecordFinder {
+	if records, isLoaded := repo.entityRepositories[entity]; isLoaded {
+		return records
+	}
+
+	switch entity {
+	case UsersGroup:
+		if repo.userRepository != nil {
+			return repo.userRepository
+		}
+	case OrganizationsGroup:
+		if repo.orgRepository != nil {
+			return repo.orgRepository
+		}
+	case TicketsGroup:
+		if repo.ticketRepository != nil {
+			return repo.ticketRepository
+		}
 	}
 
 	return nil
 }
 
-func (repo *SearchRepository) FindUsers(fieldName string, searchValue interface{}) []map[string]interface{} {
-	users := repo.userRepository.FindByField(fieldName, searchValue)
+//Schema collects the schemas of the loaded types of record. Repositories that don't know their schema use the one in DefaultSchema
+func (repo *SearchRepository) Schema() Schema {
+	schema := Schema{}
 
-	for i, user := range users {
-		org := repo.findOrgRelation(user)
-		if org != nil {
-			user["organization"] = org
+	for _, entity := range EntityGroups {
+		if repo.Loaded(entity) {
+			schema[entity] = DefaultSchema.Entity(entity)
 		}
+	}
+
+	for entity := range repo.entityRepositories {
+		schema[entity] = EntitySchema{}
+	}
 
-		if userID, isFloat := user["_id"].(float64); isFloat {
-			user["submitted_tickets"] = repo.ticketRepository.FindBySubmitter(userID)
-			user["assigned_tickets"] = repo.ticketRepository.FindByAssignee(userID)
+	for entity := range schema {
+		if holder, hasSchema := repo.finder(entity).(schemaHolder); hasSchema {
+			schema[entity] = holder.Schema()
 		}
+	}
+
+	return schema
+}
 
-		users[i] = user
+//relationsFrom is Schema().Relations, worked out once for every type of record
+func (repo *SearchRepository) relationsFrom(entity string) []Relation {
+	if repo.relations == nil {
+		schema := repo.Schema()
+		repo.relations = make(map[string][]Relation, len(schema))
+
+		for name := range schema {
+			repo.relations[name] = schema.Relations(name)
+		}
 	}
 
-	return users
+	return repo.relations[entity]
 }
 
-func (repo *SearchRepository) FindOrgs(fieldName string, searchValue interface{}) []map[string]interface{} {
-	orgs := repo.orgRepository.FindByField(fieldName, searchValue)
+//Loaded checks if the type of record was loaded
+func (repo *SearchRepository) Loaded(entity string) bool {
+	return repo.finder(entity) != nil
+}
 
-	for i, org := range orgs {
-		if orgID, isFloat := org["_id"].(float64); isFloat {
-			org["users"] = repo.userRepository.FindByOrg(orgID)
-			org["tickets"] = repo.ticketRepository.FindByOrg(orgID)
+//Entities lists the types of record that were loaded, in the order of EntityGroups
+func (repo *SearchRepository) Entities() []string {
+	var entities []string
+	for _, entity := range EntityGroups {
+		if repo.Loaded(entity) {
+			entities = append(entities, entity)
 		}
+	}
+
+	return entities
+}
+
+//valueMatcherSetter is implemented by repositories that let you change how field values are matched
+type valueMatcherSetter interface {
+	SetValueMatcher(matcherFn ValueMatcher)
+}
 
-		orgs[i] = org
+//SetValueMatcher passes the matcher on to every repository that supports it
+func (repo *SearchRepository) SetValueMatcher(matcherFn ValueMatcher) {
+	repo.valueMatcher = matcherFn
+
+	repositories := []interface{}{repo.userRepository, repo.orgRepository, repo.ticketRepository}
+	for _, records := range repo.entityRepositories {
+		repositories = append(repositories, records)
 	}
 
-	return orgs
+	for _, repository := range repositories {
+		if setter, canSet := repository.(valueMatcherSetter); canSet {
+			setter.SetValueMatcher(matcherFn)
+		}
+	}
 }
 
-func (repo *SearchRepository) FindTickets(fieldName string, searchValue interface{}) []map[string]interface{} {
-	tickets := repo.ticketRepository.FindByField(fieldName, searchValue)
+//exactMatchSetter is implemented by repositories that can skip their indexes when values aren't matched exactly
+type exactMatchSetter interface {
+	SetExactMatch(exact bool)
+}
+
+//SetExactMatch passes on whether searches only look for equal values to every repository that supports it
+func (repo *SearchRepository) SetExactMatch(exact bool) {
+	repositories := []interface{}{repo.userRepository, repo.orgRepository, repo.ticketRepository}
+	for _, records := range repo.entityRepositories {
+		repositories = append(repositories, records)
+	}
 
-	for i, ticket := range tickets {
-		org := repo.findOrgRelation(ticket)
-		if org != nil {
-			ticket["organization"] = org
+	for _, repository := range repositories {
+		if setter, canSet := repository.(exactMatchSetter); canSet {
+			setter.SetExactMatch(exact)
 		}
+	}
+}
+
+//SetMatchMode sets the matcher for one of the MatchModes, and lets the indexes be used only for MatchExact
+func (repo *SearchRepository) SetMatchMode(mode string) error {
+	matcher, err := MatcherForMode(mode)
+	if err != nil {
+		return err
+	}
+
+	repo.SetValueMatcher(matcher)
+	repo.SetExactMatch(mode == MatchExact || mode == "")
+	return nil
+}
+
+//scoredRecord copies the matched record and adds a "_score" field, plus an "_explain" field with the score of each word if explain is true.
+//The record is copied so the scores don't end up in the stored data
+func scoredRecord(match TextMatch, explain bool) map[string]interface{} {
+	record := make(map[string]interface{}, len(match.Record)+2)
+	for key, value := range match.Record {
+		record[key] = value
+	}
+
+	record["_score"] = match.Score
+	if explain {
+		record["_explain"] = match.TermScores
+	}
+
+	return record
+}
 
-		if userID, isFloat := ticket["submitter_id"].(float64); isFloat {
-			ticket["submitted_user"] = repo.userRepository.FindByID(userID)
+//scoredRecords is scoredRecord for every match
+func scoredRecords(matches []TextMatch, explain bool) []map[string]interface{} {
+	records := make([]map[string]interface{}, len(matches))
+
+	for i, match := range matches {
+		records[i] = scoredRecord(match, explain)
+	}
+
+	return records
+}
+
+//RecordHandler receives the results of the Stream methods one at a time. Returning an error stops the stream
+type RecordHandler func(record map[string]interface{}) error
+
+//streamRelations adds the relations to one record at a time and hands it to emit,
+//so only one result and its related records need to be in memory at once
+func (repo *SearchRepository) streamRelations(entity string, records []map[string]interface{}, emit RecordHandler) error {
+	for _, stored := range records {
+		if err := emit(repo.result(entity, stored)); err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
+
+//streamPage is page for the Stream methods. Without a sort, limit or offset the records are streamed in the order
+//the repository found them, so they aren't copied and sorted before the first one is handed on
+func (repo *SearchRepository) streamPage(entity string, records []map[string]interface{}, defaultSort []SortKey) []map[string]interface{} {
+	if !repo.pageOptions.paged() {
+		return records
+	}
+
+	return repo.page(entity, records, defaultSort)
+}
 
-		if userID, isFloat := ticket["assignee_id"].(float64); isFloat {
-			ticket["assigned_user"] = repo.userRepository.FindByID(userID)
+//streamTextMatches is streamRelations for text search results. The matches are only scored one at a time when there is no page to cut out
+func (repo *SearchRepository) streamTextMatches(entity string, matches []TextMatch, explain bool, emit RecordHandler) error {
+	if repo.pageOptions.paged() {
+		return repo.streamRelations(entity, repo.page(entity, scoredRecords(matches, explain), nil), emit)
+	}
+
+	for _, match := range matches {
+		if err := emit(repo.result(entity, scoredRecord(match, explain))); err != nil {
+			return err
 		}
+	}
 
-		tickets[i] = ticket
+	return nil
+}
+
+//findByField is FindByField on the repository of the type of record. Types that weren't loaded have no records
+func (repo *SearchRepository) findByField(entity string, fieldName string, searchValue interface{}) []map[string]interface{} {
+	finder := repo.finder(entity)
+	if finder == nil {
+		return []map[string]interface{}{}
 	}
 
-	return tickets
+	return finder.FindByField(fieldName, searchValue)
+}
+
+//evaluateQuery is EvaluateQuery on the repository of the type of record
+func (repo *SearchRepository) evaluateQuery(entity string, query QueryNode) []map[string]interface{} {
+	finder := repo.finder(entity)
+	if finder == nil {
+		return []map[string]interface{}{}
+	}
+
+	return EvaluateQuery(query, finder)
+}
+
+//textFinder is implemented by the repositories that have a text index
+type textFinder interface {
+	FindByText(query string) []TextMatch
+}
+
+func (repo *SearchRepository) findByText(entity string, query string) []TextMatch {
+	if finder, canSearch := repo.finder(entity).(textFinder); canSearch {
+		return finder.FindByText(query)
+	}
+
+	return nil
+}
+
+//Find searches any type of record by one field
+func (repo *SearchRepository) Find(entity string, fieldName string, searchValue interface{}) []map[string]interface{} {
+	return repo.expand(entity, repo.page(entity, repo.findByField(entity, fieldName, searchValue), DefaultSort))
+}
+
+//Stream finds the same records as Find but hands them to emit one at a time. Unless a sort or page was asked for
+//with SetPageOptions, they come in the order the repository has them rather than by "_id"
+func (repo *SearchRepository) Stream(entity string, fieldName string, searchValue interface{}, emit RecordHandler) error {
+	return repo.streamRelations(entity, repo.streamPage(entity, repo.findByField(entity, fieldName, searchValue), DefaultSort), emit)
+}
+
+//FindByText finds records of any type by the words in their indexed fields. See scoredRecords for the extra fields
+func (repo *SearchRepository) FindByText(entity string, query string, explain bool) []map[string]interface{} {
+	return repo.expand(entity, repo.page(entity, scoredRecords(repo.findByText(entity, query), explain), nil))
+}
+
+//StreamByText is FindByText one record at a time
+func (repo *SearchRepository) StreamByText(entity string, query string, explain bool, emit RecordHandler) error {
+	return repo.streamTextMatches(entity, repo.findByText(entity, query), explain, emit)
+}
+
+//Query finds the records of any type matching a parsed query
+func (repo *SearchRepository) Query(entity string, query QueryNode) []map[string]interface{} {
+	return repo.expand(entity, repo.page(entity, repo.evaluateQuery(entity, query), DefaultSort))
+}
+
+//StreamQuery is Query one record at a time
+func (repo *SearchRepository) StreamQuery(entity string, query QueryNode, emit RecordHandler) error {
+	return repo.streamRelations(entity, repo.streamPage(entity, repo.evaluateQuery(entity, query), DefaultSort), emit)
 }
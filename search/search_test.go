@@ -8,7 +8,7 @@ import (
 	"github.com/superjinjo/zendesk-search/search"
 )
 
-func Test_SearchRepository_FindUsers(t *testing.T) {
+func Test_SearchRepository_Find_Users(t *testing.T) {
 	usersRepo := new(OrgUserMockRepo)
 	orgsRepo := new(OrgUserMockRepo)
 	ticketsRepo := new(TicketMockRepo)
@@ -65,16 +65,16 @@ func Test_SearchRepository_FindUsers(t *testing.T) {
 	orgsRepo.On("FindByID", float64(22)).Return(orgs[0])
 	orgsRepo.On("FindByID", float64(33)).Return(orgs[1])
 
-	ticketsRepo.On("FindBySubmitter", float64(2)).Return([]map[string]interface{}{tickets[0]})
-	ticketsRepo.On("FindByAssignee", float64(2)).Return([]map[string]interface{}{tickets[1]})
+	ticketsRepo.On("FindByReference", "submitter_id", float64(2)).Return([]map[string]interface{}{tickets[0]})
+	ticketsRepo.On("FindByReference", "assignee_id", float64(2)).Return([]map[string]interface{}{tickets[1]})
 
-	ticketsRepo.On("FindBySubmitter", float64(1)).Return([]map[string]interface{}{tickets[1]})
-	ticketsRepo.On("FindByAssignee", float64(1)).Return([]map[string]interface{}{tickets[0]})
+	ticketsRepo.On("FindByReference", "submitter_id", float64(1)).Return([]map[string]interface{}{tickets[1]})
+	ticketsRepo.On("FindByReference", "assignee_id", float64(1)).Return([]map[string]interface{}{tickets[0]})
 
-	ticketsRepo.On("FindBySubmitter", float64(3)).Return([]map[string]interface{}{})
-	ticketsRepo.On("FindByAssignee", float64(3)).Return([]map[string]interface{}{})
+	ticketsRepo.On("FindByReference", "submitter_id", float64(3)).Return([]map[string]interface{}{})
+	ticketsRepo.On("FindByReference", "assignee_id", float64(3)).Return([]map[string]interface{}{})
 
-	results1 := repo.FindUsers("_id", float64(2))
+	results1 := repo.Find(search.UsersGroup, "_id", float64(2))
 	expected1 := []map[string]interface{}{
 		{
 			"_id":               float64(2),
@@ -88,7 +88,7 @@ func Test_SearchRepository_FindUsers(t *testing.T) {
 
 	require.Equal(t, expected1, results1)
 
-	results2 := repo.FindUsers("name", "same_name")
+	results2 := repo.Find(search.UsersGroup, "name", "same_name")
 	expected2 := []map[string]interface{}{
 		{
 			"_id":               float64(1),
@@ -110,7 +110,7 @@ func Test_SearchRepository_FindUsers(t *testing.T) {
 
 }
 
-func Test_SearchRepository_FindOrgs(t *testing.T) {
+func Test_SearchRepository_Find_Orgs(t *testing.T) {
 	usersRepo := new(OrgUserMockRepo)
 	orgsRepo := new(OrgUserMockRepo)
 	ticketsRepo := new(TicketMockRepo)
@@ -165,13 +165,13 @@ func Test_SearchRepository_FindOrgs(t *testing.T) {
 	orgsRepo.On("FindByField", "_id", float64(22)).Return([]map[string]interface{}{orgs[0]})
 	orgsRepo.On("FindByField", "name", "org 2").Return([]map[string]interface{}{orgs[1]})
 
-	ticketsRepo.On("FindByOrg", float64(22)).Return([]map[string]interface{}{tickets[0], tickets[1]})
-	ticketsRepo.On("FindByOrg", float64(33)).Return([]map[string]interface{}{})
+	ticketsRepo.On("FindByReference", "organization_id", float64(22)).Return([]map[string]interface{}{tickets[0], tickets[1]})
+	ticketsRepo.On("FindByReference", "organization_id", float64(33)).Return([]map[string]interface{}{})
 
-	usersRepo.On("FindByOrg", float64(22)).Return([]map[string]interface{}{users[0]})
-	usersRepo.On("FindByOrg", float64(33)).Return([]map[string]interface{}{users[1]})
+	usersRepo.On("FindByReference", "organization_id", float64(22)).Return([]map[string]interface{}{users[0]})
+	usersRepo.On("FindByReference", "organization_id", float64(33)).Return([]map[string]interface{}{users[1]})
 
-	results1 := repo.FindOrgs("_id", float64(22))
+	results1 := repo.Find(search.OrganizationsGroup, "_id", float64(22))
 	expected1 := []map[string]interface{}{
 		{
 			"_id":     float64(22),
@@ -183,7 +183,7 @@ func Test_SearchRepository_FindOrgs(t *testing.T) {
 
 	require.Equal(t, expected1, results1)
 
-	results2 := repo.FindOrgs("name", "org 2")
+	results2 := repo.Find(search.OrganizationsGroup, "name", "org 2")
 	expected2 := []map[string]interface{}{
 		{
 			"_id":     float64(33),
@@ -196,7 +196,7 @@ func Test_SearchRepository_FindOrgs(t *testing.T) {
 	require.Equal(t, expected2, results2)
 }
 
-func Test_SearchRepository_FindTickets(t *testing.T) {
+func Test_SearchRepository_Find_Tickets(t *testing.T) {
 
 	usersRepo := new(OrgUserMockRepo)
 	orgsRepo := new(OrgUserMockRepo)
@@ -255,7 +255,7 @@ func Test_SearchRepository_FindTickets(t *testing.T) {
 	usersRepo.On("FindByID", float64(1)).Return(users[0])
 	usersRepo.On("FindByID", float64(2)).Return(users[1])
 
-	results1 := repo.FindTickets("_id", "abcd")
+	results1 := repo.Find(search.TicketsGroup, "_id", "abcd")
 	expected1 := []map[string]interface{}{
 		{
 			"_id":             "abcd",
@@ -271,7 +271,7 @@ func Test_SearchRepository_FindTickets(t *testing.T) {
 
 	require.Equal(t, expected1, results1)
 
-	results2 := repo.FindTickets("subject", "ticket 2")
+	results2 := repo.Find(search.TicketsGroup, "subject", "ticket 2")
 	expected2 := []map[string]interface{}{
 		{
 			"_id":             "efgh",
@@ -289,6 +289,115 @@ func Test_SearchRepository_FindTickets(t *testing.T) {
 
 }
 
+func Test_SearchRepository_FindByText(t *testing.T) {
+	usersRepo := new(OrgUserMockRepo)
+	orgsRepo := new(OrgUserMockRepo)
+	ticketsRepo := new(TicketMockRepo)
+
+	repo := search.NewSearchRepository(usersRepo, orgsRepo, ticketsRepo)
+
+	tickets := []map[string]interface{}{
+		{
+			"_id":     "abcd",
+			"subject": "A Catastrophe in Korea (North)",
+		},
+		{
+			"_id":     "efgh",
+			"subject": "A Problem in Korea (South)",
+		},
+	}
+
+	termScores := map[string]search.TermScore{
+		"korea": {Score: 1.5, Frequency: 1, Matches: 2, IDF: 0.2},
+	}
+
+	ticketsRepo.On("FindByText", "korea").Return([]search.TextMatch{
+		{Record: tickets[1], Score: 2.5, TermScores: termScores},
+		{Record: tickets[0], Score: 1.5, TermScores: termScores},
+	})
+
+	results1 := repo.FindByText(search.TicketsGroup, "korea", false)
+	expected1 := []map[string]interface{}{
+		{
+			"_id":     "efgh",
+			"subject": "A Problem in Korea (South)",
+			"_score":  2.5,
+		},
+		{
+			"_id":     "abcd",
+			"subject": "A Catastrophe in Korea (North)",
+			"_score":  1.5,
+		},
+	}
+
+	require.Equal(t, expected1, results1)
+
+	results2 := repo.FindByText(search.TicketsGroup, "korea", true)
+	require.Equal(t, termScores, results2[0]["_explain"])
+
+	//the scores are only added to copies
+	require.NotContains(t, tickets[0], "_score")
+	require.NotContains(t, tickets[1], "_explain")
+}
+
+func Test_SearchRepository_DoesNotChangeStoredRecords(t *testing.T) {
+	users, err := search.NewUserJSONRepository([]map[string]interface{}{
+		{"_id": float64(1), "name": "user 1", "organization_id": float64(22), "tags": []interface{}{"a", "b"}},
+		{"_id": float64(2), "name": "user 2"},
+	})
+	require.Nil(t, err)
+
+	orgs, err := search.NewOrgJSONRepository([]map[string]interface{}{
+		{"_id": float64(22), "name": "org 1"},
+	})
+	require.Nil(t, err)
+
+	tickets, err := search.NewTicketJSONRepository([]map[string]interface{}{
+		{"_id": "abcd", "subject": "ticket 1", "submitter_id": float64(1), "assignee_id": float64(2), "organization_id": float64(22)},
+	})
+	require.Nil(t, err)
+
+	repo := search.NewSearchRepository(users, orgs, tickets)
+
+	storedUser := users.FindByID(1)
+	storedOrg := orgs.FindByID(22)
+	storedTicket := tickets.FindByID("abcd")
+
+	expectedUser := map[string]interface{}{"_id": float64(1), "name": "user 1", "organization_id": float64(22), "tags": []interface{}{"a", "b"}}
+	expectedOrg := map[string]interface{}{"_id": float64(22), "name": "org 1"}
+	expectedTicket := map[string]interface{}{"_id": "abcd", "subject": "ticket 1", "submitter_id": float64(1), "assignee_id": float64(2), "organization_id": float64(22)}
+
+	searches := []func() []map[string]interface{}{
+		func() []map[string]interface{} { return repo.Find(search.UsersGroup, "_id", float64(1)) },
+		func() []map[string]interface{} { return repo.Find(search.OrganizationsGroup, "_id", float64(22)) },
+		func() []map[string]interface{} { return repo.Find(search.TicketsGroup, "_id", "abcd") },
+	}
+
+	for _, find := range searches {
+		first := find()
+		second := find()
+
+		//running the same search again gives the same results instead of nesting the relations deeper
+		require.Equal(t, first, second)
+
+		require.Equal(t, expectedUser, storedUser)
+		require.Equal(t, expectedOrg, storedOrg)
+		require.Equal(t, expectedTicket, storedTicket)
+	}
+
+	//changing a result doesn't change the stored data either
+	result := repo.Find(search.UsersGroup, "_id", float64(1))[0]
+	result["tags"].([]interface{})[0] = "changed"
+	result["organization"].(map[string]interface{})["name"] = "changed"
+	repo.Find(search.TicketsGroup, "_id", "abcd")[0]["submitted_user"].(map[string]interface{})["name"] = "changed"
+
+	require.Equal(t, expectedUser, storedUser)
+	require.Equal(t, expectedOrg, storedOrg)
+
+	//relations are not searchable as if they were fields
+	require.Empty(t, users.FindByField("organization", "org 1"))
+}
+
 type MockRepository struct {
 	mock.Mock
 }
@@ -299,12 +408,30 @@ func (m *MockRepository) FindByOrg(orgID float64) []map[string]interface{} {
 	return args.Get(0).([]map[string]interface{})
 }
 
+func (m *MockRepository) FindByReference(fieldName string, id interface{}) []map[string]interface{} {
+	args := m.Called(fieldName, id)
+
+	return args.Get(0).([]map[string]interface{})
+}
+
 func (m *MockRepository) FindByField(fieldName string, searchVal interface{}) []map[string]interface{} {
 	args := m.Called(fieldName, searchVal)
 
 	return args.Get(0).([]map[string]interface{})
 }
 
+func (m *MockRepository) FindAll() []map[string]interface{} {
+	args := m.Called()
+
+	return args.Get(0).([]map[string]interface{})
+}
+
+func (m *MockRepository) FindByText(query string) []search.TextMatch {
+	args := m.Called(query)
+
+	return args.Get(0).([]search.TextMatch)
+}
+
 type OrgUserMockRepo struct {
 	MockRepository
 }
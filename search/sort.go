@@ -0,0 +1,248 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+//SortKey is one field to sort results by. Later keys break ties between records that are equal on the earlier ones
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+//DefaultSort orders results by "_id" so the same search always prints the same thing
+var DefaultSort = []SortKey{{Field: "_id"}}
+
+//ParseSortKey reads a sort key like "created_at", "created_at:asc" or "priority:desc"
+func ParseSortKey(text string) (SortKey, error) {
+	parts := strings.SplitN(text, ":", 2)
+	key := SortKey{Field: parts[0]}
+
+	if key.Field == "" {
+		return key, fmt.Errorf(`Invalid sort "%v", expected a field like created_at:desc`, text)
+	}
+
+	if len(parts) == 2 {
+		switch strings.ToLower(parts[1]) {
+		case "asc":
+		case "desc":
+			key.Descending = true
+		default:
+			return key, fmt.Errorf(`Invalid sort "%v", the direction must be asc or desc`, text)
+		}
+	}
+
+	return key, nil
+}
+
+//PageOptions decide the order of the results and which part of them is returned.
+//A Limit of 0 returns everything after Offset. If Sort is empty, the search's own order is used
+type PageOptions struct {
+	Sort   []SortKey
+	Limit  int
+	Offset int
+}
+
+//paged is true when the options change the order or number of results
+func (options PageOptions) paged() bool {
+	return len(options.Sort) > 0 || options.Limit > 0 || options.Offset > 0
+}
+
+//SetPageOptions changes the order and number of results returned by Find, Query and the like
+func (repo *SearchRepository) SetPageOptions(options PageOptions) {
+	repo.pageOptions = options
+}
+
+//schemaHolder is a repository that knows the schema of its records
+type schemaHolder interface {
+	Schema() EntitySchema
+}
+
+//page sorts the records of the type and cuts out the page asked for. defaultSort is used when no sort was asked for,
+//text searches pass nil to keep the most relevant results first
+func (repo *SearchRepository) page(entity string, records []map[string]interface{}, defaultSort []SortKey) []map[string]interface{} {
+	keys := repo.pageOptions.Sort
+	if len(keys) == 0 {
+		keys = defaultSort
+	}
+
+	//copy the slice so the repositories' own slices are never reordered
+	sorted := append([]map[string]interface{}{}, records...)
+	if holder, hasSchema := repo.finder(entity).(schemaHolder); hasSchema {
+		SortRecordsBySchema(sorted, keys, holder.Schema())
+	} else {
+		SortRecords(sorted, keys)
+	}
+
+	if repo.pageOptions.Offset >= len(sorted) {
+		return []map[string]interface{}{}
+	}
+
+	sorted = sorted[repo.pageOptions.Offset:]
+
+	if repo.pageOptions.Limit > 0 && repo.pageOptions.Limit < len(sorted) {
+		sorted = sorted[:repo.pageOptions.Limit]
+	}
+
+	return sorted
+}
+
+//SortRecords sorts the records by the keys. The sort is stable so records that are equal on every key keep their order.
+//Missing and empty values always come last, whichever direction the sort is in. Without a schema, text is only sorted as
+//dates for fields where every value is a timestamp, see SortRecordsBySchema
+func SortRecords(records []map[string]interface{}, keys []SortKey) {
+	dateFields := map[string]bool{}
+	for _, key := range keys {
+		dateFields[key.Field] = allTimestamps(records, key.Field)
+	}
+
+	sortRecords(records, keys, dateFields)
+}
+
+//SortRecordsBySchema is SortRecords for records with a schema, which says which fields hold dates
+func SortRecordsBySchema(records []map[string]interface{}, keys []SortKey, schema EntitySchema) {
+	dateFields := map[string]bool{}
+	for _, fieldName := range schema.FieldsOfType(FieldDateTime) {
+		dateFields[fieldName] = true
+	}
+
+	sortRecords(records, keys, dateFields)
+}
+
+//allTimestamps checks if every text value of the field is a timestamp
+func allTimestamps(records []map[string]interface{}, fieldName string) bool {
+	found := false
+	for _, record := range records {
+		if text, isString := record[fieldName].(string); isString && text != "" {
+			if _, err := ParseDataTime(text); err != nil {
+				return false
+			}
+
+			found = true
+		}
+	}
+
+	return found
+}
+
+//sortValue is a value parsed once before sorting, so dates aren't parsed again in every comparison
+type sortValue struct {
+	empty  bool
+	rank   int
+	number float64
+	date   time.Time
+	text   string
+}
+
+//newSortValue parses the value for sorting. Text is only read as a date if isDate is set
+func newSortValue(value interface{}, isDate bool) sortValue {
+	if value == nil || value == "" {
+		return sortValue{empty: true}
+	}
+
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return sortValue{rank: 0, number: 1}
+		}
+
+		return sortValue{rank: 0}
+	case float64, int:
+		number, _ := floatVal(v)
+		return sortValue{rank: 1, number: number}
+	case string:
+		if isDate {
+			if date, err := ParseDataTime(v); err == nil {
+				return sortValue{rank: 2, date: date}
+			}
+		}
+
+		return sortValue{rank: 3, text: strings.ToLower(v)}
+	default:
+		return sortValue{rank: 4, text: strings.ToLower(stringVal(v))}
+	}
+}
+
+func sortRecords(records []map[string]interface{}, keys []SortKey, dateFields map[string]bool) {
+	if len(keys) == 0 {
+		return
+	}
+
+	//the values are parsed once for each record, and the sort moves the records and their values together
+	values := make([][]sortValue, len(records))
+	for i, record := range records {
+		values[i] = make([]sortValue, len(keys))
+		for k, key := range keys {
+			values[i][k] = newSortValue(record[key.Field], dateFields[key.Field])
+		}
+	}
+
+	sort.Stable(recordSorter{records: records, values: values, keys: keys})
+}
+
+type recordSorter struct {
+	records []map[string]interface{}
+	values  [][]sortValue
+	keys    []SortKey
+}
+
+func (sorter recordSorter) Len() int {
+	return len(sorter.records)
+}
+
+func (sorter recordSorter) Swap(i, j int) {
+	sorter.records[i], sorter.records[j] = sorter.records[j], sorter.records[i]
+	sorter.values[i], sorter.values[j] = sorter.values[j], sorter.values[i]
+}
+
+func (sorter recordSorter) Less(i, j int) bool {
+	for k, key := range sorter.keys {
+		left, right := sorter.values[i][k], sorter.values[j][k]
+
+		if left.empty || right.empty {
+			if left.empty == right.empty {
+				continue
+			}
+
+			return right.empty
+		}
+
+		comparison := compareSortValues(left, right)
+		if comparison == 0 {
+			continue
+		}
+
+		if key.Descending {
+			return comparison > 0
+		}
+
+		return comparison < 0
+	}
+
+	return false
+}
+
+//compareSortValues returns a negative number if left comes first, a positive one if right does and 0 if they are equal.
+//Values of different types are ordered booleans, then numbers, then dates, then text, then lists
+func compareSortValues(left sortValue, right sortValue) int {
+	if left.rank != right.rank {
+		return left.rank - right.rank
+	}
+
+	switch {
+	case left.rank == 2 && left.date.Before(right.date), left.rank != 2 && left.number < right.number:
+		return -1
+	case left.rank == 2 && left.date.After(right.date), left.rank != 2 && left.number > right.number:
+		return 1
+	default:
+		return strings.Compare(left.text, right.text)
+	}
+}
+
+//compareValues compares two values the way the results are sorted, reading any text that is a timestamp as a date
+func compareValues(left interface{}, right interface{}) int {
+	return compareSortValues(newSortValue(left, true), newSortValue(right, true))
+}
@@ -0,0 +1,128 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+func Test_ParseSortKey(t *testing.T) {
+	key, err := search.ParseSortKey("created_at")
+	require.Nil(t, err)
+	require.Equal(t, search.SortKey{Field: "created_at"}, key)
+
+	key, err = search.ParseSortKey("created_at:asc")
+	require.Nil(t, err)
+	require.Equal(t, search.SortKey{Field: "created_at"}, key)
+
+	key, err = search.ParseSortKey("priority:DESC")
+	require.Nil(t, err)
+	require.Equal(t, search.SortKey{Field: "priority", Descending: true}, key)
+
+	_, err = search.ParseSortKey("priority:up")
+	require.EqualError(t, err, `Invalid sort "priority:up", the direction must be asc or desc`)
+
+	_, err = search.ParseSortKey(":desc")
+	require.NotNil(t, err)
+}
+
+func recordIDs(records []map[string]interface{}) []interface{} {
+	ids := make([]interface{}, len(records))
+	for i, record := range records {
+		ids[i] = record["_id"]
+	}
+
+	return ids
+}
+
+func Test_SortRecords(t *testing.T) {
+	records := []map[string]interface{}{
+		{"_id": float64(10), "name": "bob", "active": true, "created_at": "2016-04-28T11:19:34 -10:00"},
+		{"_id": float64(2), "name": "Alice", "active": false, "created_at": "2016-04-28T10:19:34 -11:00"},
+		{"_id": float64(33), "active": true},
+		{"_id": float64(4), "name": "alice", "active": false, "created_at": "2015-01-01T00:00:00 -10:00"},
+	}
+
+	//numbers are compared as numbers, not text
+	search.SortRecords(records, []search.SortKey{{Field: "_id"}})
+	require.Equal(t, []interface{}{float64(2), float64(4), float64(10), float64(33)}, recordIDs(records))
+
+	search.SortRecords(records, []search.SortKey{{Field: "_id", Descending: true}})
+	require.Equal(t, []interface{}{float64(33), float64(10), float64(4), float64(2)}, recordIDs(records))
+
+	//text ignores case, and missing values come last in both directions
+	search.SortRecords(records, []search.SortKey{{Field: "name"}, {Field: "_id", Descending: true}})
+	require.Equal(t, []interface{}{float64(4), float64(2), float64(10), float64(33)}, recordIDs(records))
+
+	search.SortRecords(records, []search.SortKey{{Field: "name", Descending: true}, {Field: "_id"}})
+	require.Equal(t, []interface{}{float64(10), float64(2), float64(4), float64(33)}, recordIDs(records))
+
+	//dates are compared as times, so the time zone offsets count
+	search.SortRecords(records, []search.SortKey{{Field: "created_at"}})
+	require.Equal(t, []interface{}{float64(4), float64(10), float64(2), float64(33)}, recordIDs(records))
+
+	search.SortRecords(records, []search.SortKey{{Field: "active"}, {Field: "_id"}})
+	require.Equal(t, []interface{}{float64(2), float64(4), float64(10), float64(33)}, recordIDs(records))
+}
+
+func Test_SortRecords_TextThatLooksLikeDates(t *testing.T) {
+	records := []map[string]interface{}{
+		{"_id": float64(1), "signature": "Don't Worry Be Happy!"},
+		{"_id": float64(2), "signature": "2016-04-28T11:19:34 -10:00"},
+		{"_id": float64(3), "signature": "Be Happy"},
+	}
+
+	//one signature happens to be a timestamp, but the others aren't so the field is sorted as text
+	search.SortRecords(records, []search.SortKey{{Field: "signature"}})
+	require.Equal(t, []interface{}{float64(2), float64(3), float64(1)}, recordIDs(records))
+
+	//the schema says the signature is text even when every value is a timestamp
+	records = []map[string]interface{}{
+		{"_id": float64(1), "signature": "2016-04-28T11:19:34 -10:00"},
+		{"_id": float64(2), "signature": "2016-04-28T10:19:34 -11:00"},
+	}
+
+	search.SortRecordsBySchema(records, []search.SortKey{{Field: "signature"}}, search.DefaultSchema.Entity(search.UsersGroup))
+	require.Equal(t, []interface{}{float64(2), float64(1)}, recordIDs(records))
+
+	//and created_at is a date, so the offsets count
+	records = []map[string]interface{}{
+		{"_id": float64(1), "created_at": "2016-04-28T10:19:34 -12:00"},
+		{"_id": float64(2), "created_at": "2016-04-28T11:19:34 -10:00"},
+	}
+
+	search.SortRecordsBySchema(records, []search.SortKey{{Field: "created_at"}}, search.DefaultSchema.Entity(search.UsersGroup))
+	require.Equal(t, []interface{}{float64(2), float64(1)}, recordIDs(records))
+}
+
+func Test_SearchRepository_SetPageOptions(t *testing.T) {
+	users, err := search.NewUserJSONRepository([]map[string]interface{}{
+		{"_id": float64(3), "name": "c", "role": "admin"},
+		{"_id": float64(1), "name": "a", "role": "admin"},
+		{"_id": float64(2), "name": "b", "role": "admin"},
+		{"_id": float64(4), "name": "d", "role": "agent"},
+	})
+	require.Nil(t, err)
+
+	orgs, err := search.NewOrgJSONRepository([]map[string]interface{}{})
+	require.Nil(t, err)
+
+	tickets, err := search.NewTicketJSONRepository([]map[string]interface{}{})
+	require.Nil(t, err)
+
+	repo := search.NewSearchRepository(users, orgs, tickets)
+	repo.SetExpandOptions(search.ExpandOptions{Depth: 0})
+
+	//ordered by _id when no sort is asked for
+	require.Equal(t, []interface{}{float64(1), float64(2), float64(3)}, recordIDs(repo.Find(search.UsersGroup, "role", "admin")))
+
+	repo.SetPageOptions(search.PageOptions{Sort: []search.SortKey{{Field: "name", Descending: true}}, Limit: 2})
+	require.Equal(t, []interface{}{float64(3), float64(2)}, recordIDs(repo.Find(search.UsersGroup, "role", "admin")))
+
+	repo.SetPageOptions(search.PageOptions{Limit: 2, Offset: 2})
+	require.Equal(t, []interface{}{float64(3)}, recordIDs(repo.Find(search.UsersGroup, "role", "admin")))
+
+	repo.SetPageOptions(search.PageOptions{Offset: 5})
+	require.Equal(t, []map[string]interface{}{}, repo.Find(search.UsersGroup, "role", "admin"))
+}
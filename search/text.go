@@ -0,0 +1,190 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//BM25 tuning values. These are the usual defaults (Lucene and Elasticsearch use the same ones)
+const (
+	bm25K1 = 1.2  //how quickly repeating a word stops adding to the score
+	bm25B  = 0.75 //how much long records are penalised
+)
+
+//stopWords are the English stop words Lucene leaves out by default. They appear in almost every record so they don't help narrow a search
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "but": true,
+	"by": true, "for": true, "if": true, "in": true, "into": true, "is": true, "it": true, "no": true,
+	"not": true, "of": true, "on": true, "or": true, "such": true, "that": true, "the": true, "their": true,
+	"then": true, "there": true, "these": true, "they": true, "this": true, "to": true, "was": true,
+	"will": true, "with": true,
+}
+
+//Tokenize lowercases the text and splits it into words, leaving out punctuation and stop words
+func Tokenize(text string) []string {
+	var tokens []string
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(char rune) bool {
+		//apostrophes are dropped rather than split on so "don't" stays one word
+		return !unicode.IsLetter(char) && !unicode.IsDigit(char) && char != '\''
+	})
+
+	for _, word := range words {
+		word = strings.Replace(word, "'", "", -1)
+
+		if word != "" && !stopWords[word] {
+			tokens = append(tokens, word)
+		}
+	}
+
+	return tokens
+}
+
+//TermScore explains how much one word of the query added to a record's score
+type TermScore struct {
+	Score     float64 `json:"score"`
+	Frequency int     `json:"frequency"` //times the word appears in the record
+	Matches   int     `json:"matches"`   //number of records that have the word
+	IDF       float64 `json:"idf"`       //rarer words are worth more
+}
+
+//TextMatch is a record found by a text search along with its BM25 relevance score
+type TextMatch struct {
+	Record     map[string]interface{}
+	Score      float64
+	TermScores map[string]TermScore
+}
+
+//textIndex is an inverted index from words to the records that contain them
+type textIndex struct {
+	fields      []string
+	records     []map[string]interface{}
+	lengths     []int                  //number of words in each record
+	totalLength int                    //used for the average record length
+	postings    map[string]map[int]int //map of record positions indexed by word, with the number of times the word appears in the record
+}
+
+func newTextIndex(fields ...string) *textIndex {
+	return &textIndex{
+		fields:   fields,
+		postings: make(map[string]map[int]int),
+	}
+}
+
+func (index *textIndex) add(record map[string]interface{}) {
+	position := len(index.records)
+	index.records = append(index.records, record)
+
+	length := 0
+
+	for _, fieldName := range index.fields {
+		for _, fieldValue := range sliceVal(record[fieldName]) {
+			for _, token := range Tokenize(stringVal(fieldValue)) {
+				if index.postings[token] == nil {
+					index.postings[token] = make(map[int]int)
+				}
+
+				index.postings[token][position]++
+				length++
+			}
+		}
+	}
+
+	index.lengths = append(index.lengths, length)
+	index.totalLength += length
+}
+
+func (index *textIndex) idf(token string) float64 {
+	recordCount := float64(len(index.records))
+	matchCount := float64(len(index.postings[token]))
+
+	return math.Log(1 + (recordCount-matchCount+0.5)/(matchCount+0.5))
+}
+
+//score works out the BM25 score of one record for the given query words
+func (index *textIndex) score(position int, tokens []string) (float64, map[string]TermScore) {
+	averageLength := float64(index.totalLength) / float64(len(index.records))
+	lengthRatio := float64(index.lengths[position]) / averageLength
+
+	total := 0.0
+	termScores := make(map[string]TermScore, len(tokens))
+
+	for _, token := range tokens {
+		if _, counted := termScores[token]; counted {
+			continue
+		}
+
+		frequency := index.postings[token][position]
+		idf := index.idf(token)
+		tf := float64(frequency)
+
+		termScore := idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*lengthRatio))
+
+		termScores[token] = TermScore{
+			Score:     termScore,
+			Frequency: frequency,
+			Matches:   len(index.postings[token]),
+			IDF:       idf,
+		}
+		total += termScore
+	}
+
+	return total, termScores
+}
+
+//search returns the records that contain every word in the query, the most relevant first
+func (index *textIndex) search(query string) []TextMatch {
+	results := []TextMatch{}
+
+	tokens := Tokenize(query)
+	if len(tokens) == 0 {
+		return results
+	}
+
+	//start from the rarest word so there are as few candidates as possible to check
+	rarest := index.postings[tokens[0]]
+	for _, token := range tokens[1:] {
+		if len(index.postings[token]) < len(rarest) {
+			rarest = index.postings[token]
+		}
+	}
+
+	//map order is random, so the candidates are put back in the order the records were added before scoring
+	candidates := make([]int, 0, len(rarest))
+	for position := range rarest {
+		candidates = append(candidates, position)
+	}
+
+	sort.Ints(candidates)
+
+	for _, position := range candidates {
+		hasAll := true
+		for _, token := range tokens {
+			if _, hasWord := index.postings[token][position]; !hasWord {
+				hasAll = false
+				break
+			}
+		}
+
+		if !hasAll {
+			continue
+		}
+
+		score, termScores := index.score(position, tokens)
+
+		results = append(results, TextMatch{
+			Record:     index.records[position],
+			Score:      score,
+			TermScores: termScores,
+		})
+	}
+
+	//stable so that records with the same score stay in the order they were added
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	return results
+}
@@ -0,0 +1,43 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/superjinjo/zendesk-search/search"
+)
+
+func Test_Tokenize(t *testing.T) {
+	require.Equal(t, []string{"catastrophe", "korea", "north"}, search.Tokenize("A Catastrophe in Korea (North)"))
+	require.Equal(t, []string{"dont", "worry", "happy"}, search.Tokenize("Don't Worry Be Happy!"))
+	require.Equal(t, []string{"100", "sure"}, search.Tokenize("100% sure"))
+	require.Nil(t, search.Tokenize("the and of"))
+	require.Nil(t, search.Tokenize(""))
+}
+
+func Test_TextSearch_Candidates(t *testing.T) {
+	records := []map[string]interface{}{}
+	for i := 0; i < 20; i++ {
+		records = append(records, map[string]interface{}{"_id": float64(i), "body": "printer jammed again"})
+	}
+
+	records[3]["body"] = "the printer is on fire"
+	records[12]["body"] = "fire drill, printer unplugged"
+
+	repo, err := search.NewEntityRepository(records, search.DefaultSchema.Entity(search.TicketCommentsGroup))
+	require.Nil(t, err)
+
+	//only the records with both words are found, the shorter one first
+	matches := repo.FindByText("fire printer")
+	require.Len(t, matches, 2)
+	require.Equal(t, float64(3), matches[0].Record["_id"])
+	require.Equal(t, float64(12), matches[1].Record["_id"])
+
+	//equal scores keep the order the records were loaded in
+	jammed := repo.FindByText("jammed")
+	require.Len(t, jammed, 18)
+	require.Equal(t, float64(0), jammed[0].Record["_id"])
+	require.Equal(t, float64(19), jammed[17].Record["_id"])
+	require.Empty(t, repo.FindByText("jammed fire"))
+	require.Empty(t, repo.FindByText("toner"))
+}
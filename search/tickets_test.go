@@ -359,3 +359,87 @@ func Test_TicketJSONRepository_FindByField(t *testing.T) {
 	}
 
 }
+
+func Test_TicketJSONRepository_FindByText(t *testing.T) {
+	ticketList := []map[string]interface{}{
+		{
+			"_id":         "436bf9b0-1147-4c0a-8439-6f79833bff5b",
+			"subject":     "A Catastrophe in Korea (North)",
+			"description": "Nostrud ad sit velit cupidatat laboris ipsum nisi amet laboris ex exercitation amet et proident.",
+		},
+		{
+			"_id":         "1a227508-9f39-427c-8f57-1b72f3fab87c",
+			"subject":     "A Catastrophe in Micronesia",
+			"description": "Aliquip excepteur fugiat ex minim ea aute eu labore. Sunt eiusmod esse eu non commodo est veniam consequat.",
+		},
+		{
+			"_id":     "2217c7dc-7371-4401-8738-0a8a8aedc08d",
+			"subject": "A Problem in Korea (South)",
+		},
+	}
+	repository, err := search.NewTicketJSONRepository(ticketList)
+	require.Nil(t, err)
+
+	records := func(matches []search.TextMatch) []map[string]interface{} {
+		found := []map[string]interface{}{}
+		for _, match := range matches {
+			found = append(found, match.Record)
+		}
+		return found
+	}
+
+	require.ElementsMatch(t, []map[string]interface{}{ticketList[0], ticketList[1]}, records(repository.FindByText("catastrophe")))
+	require.Equal(t, []map[string]interface{}{ticketList[0]}, records(repository.FindByText("Catastrophe KOREA")))
+
+	//words can come from either field
+	require.Equal(t, []map[string]interface{}{ticketList[0]}, records(repository.FindByText("korea laboris")))
+
+	require.Empty(t, repository.FindByText("catastrophe south"))
+	require.Empty(t, repository.FindByText("the"))
+}
+
+func Test_TicketJSONRepository_FindByText_Ranking(t *testing.T) {
+	ticketList := []map[string]interface{}{
+		{
+			"_id":         "long",
+			"subject":     "A Problem in Malawi",
+			"description": "Lorem ipsum eiusmod pariatur enim problem qui aliquip voluptate cupidatat eiusmod anim fugiat veniam.",
+		},
+		{
+			"_id":     "short",
+			"subject": "A Problem in Kiribati",
+		},
+		{
+			"_id":         "repeated",
+			"subject":     "A Problem in Tuvalu",
+			"description": "Problem after problem.",
+		},
+		{
+			"_id":     "other",
+			"subject": "A Nuisance in Ghana",
+		},
+	}
+	repository, err := search.NewTicketJSONRepository(ticketList)
+	require.Nil(t, err)
+
+	matches := repository.FindByText("problem")
+	require.Len(t, matches, 3)
+
+	//repeating the word helps, and a short record beats a long one with the same count
+	require.Equal(t, "repeated", matches[0].Record["_id"])
+	require.Equal(t, "short", matches[1].Record["_id"])
+	require.Equal(t, "long", matches[2].Record["_id"])
+
+	for _, match := range matches {
+		require.True(t, match.Score > 0)
+		require.Equal(t, match.Score, match.TermScores["problem"].Score)
+		require.Equal(t, 3, match.TermScores["problem"].Matches)
+	}
+
+	require.Equal(t, 3, matches[0].TermScores["problem"].Frequency)
+
+	//rarer words are worth more
+	rareMatch := repository.FindByText("kiribati problem")
+	require.Len(t, rareMatch, 1)
+	require.True(t, rareMatch[0].TermScores["kiribati"].Score > rareMatch[0].TermScores["problem"].Score)
+}